@@ -1,32 +1,48 @@
 package controllers
 
 import (
+	"log"
+	"net/http"
+
 	"mygola/app/models"
+	"mygola/pkg/database"
 	"mygola/pkg/gola"
 	"mygola/pkg/view"
 )
 
 type BlogController struct {
 	templateEngine *view.TemplateEngine
+	orm            *database.ORM
 }
 
-func NewBlogController(templateEngine *view.TemplateEngine) *BlogController {
-	return &BlogController{templateEngine: templateEngine}
+func NewBlogController(templateEngine *view.TemplateEngine, orm *database.ORM) *BlogController {
+	return &BlogController{templateEngine: templateEngine, orm: orm}
 }
 
+// Index lists the posts, newest first, 15 per page
 func (c *BlogController) Index(ctx *gola.Context) {
-	posts := []models.Post{
-		{ID: "1", Title: "First Post", Content: "This is the first post"},
-		{ID: "2", Title: "Second Post", Content: "This is the second post"},
+	if c.orm == nil {
+		ctx.Error(http.StatusServiceUnavailable, "Database unavailable")
+		return
 	}
 
-	data := map[string]interface{}{
-		"Title": "Blog",
-		"Posts": posts,
+	var posts []models.Post
+	page, err := c.orm.Model(&models.Post{}).OrderBy("created_at", "desc").PaginateRequest(ctx.Request, 15, &posts)
+	if err != nil {
+		log.Printf("blog index: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Internal Server Error")
+		return
 	}
-	ctx.JSON(200, data)
 
-	//c.templateEngine.Render(ctx.Writer, "blog/index", data)
+	if ctx.WantsJSON() {
+		ctx.JSON(http.StatusOK, page)
+		return
+	}
+	ctx.Render(http.StatusOK, "blog/index", map[string]interface{}{
+		"Title":      "Blog",
+		"Posts":      posts,
+		"Pagination": page,
+	})
 }
 
 func (c *BlogController) Show(ctx *gola.Context) {
@@ -23,4 +23,12 @@ func NewProduct() *Product {
 	}
 }
 
+// BeforeCreate gives products created without NewProduct an ID
+func (m *Product) BeforeCreate() error {
+	if m.ID == "" {
+		m.ID = helpers.GenerateID()
+	}
+	return nil
+}
+
 // Add your model methods here
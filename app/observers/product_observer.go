@@ -0,0 +1,46 @@
+// app/observers/product_observer.go
+package observers
+
+import (
+	"errors"
+	"log"
+
+	"mygola/app/models"
+	"mygola/pkg/cache"
+	"mygola/pkg/database"
+)
+
+// ProductsCacheKey holds the cached product listing
+const ProductsCacheKey = "products:all"
+
+// ProductObserver drops cached product data whenever a product changes
+type ProductObserver struct {
+	cache cache.Cache
+}
+
+func NewProductObserver(c cache.Cache) *ProductObserver {
+	return &ProductObserver{cache: c}
+}
+
+func (o *ProductObserver) Saved(model database.Model) error {
+	o.forget(model)
+	return nil
+}
+
+func (o *ProductObserver) Deleted(model database.Model) error {
+	o.forget(model)
+	return nil
+}
+
+func (o *ProductObserver) forget(model database.Model) {
+	product, ok := model.(*models.Product)
+	if !ok {
+		return
+	}
+	for _, key := range []string{ProductsCacheKey, "product:" + product.ID} {
+		// A stale entry is worse than a failed write, so only log it
+		if err := o.cache.Delete(key); err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+			log.Printf("product observer: forget %s: %v", key, err)
+		}
+	}
+}
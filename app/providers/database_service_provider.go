@@ -0,0 +1,21 @@
+package providers
+
+import (
+	"mygola/config"
+	"mygola/pkg/database"
+	"mygola/pkg/foundation"
+)
+
+type DatabaseServiceProvider struct{}
+
+func NewDatabaseServiceProvider() *DatabaseServiceProvider {
+	return &DatabaseServiceProvider{}
+}
+
+// Nothing to bind, the ORM is built per connection by database.ORM
+func (p *DatabaseServiceProvider) Register(app *foundation.Application) {}
+
+// Lazy loads the ORM prevents fail loudly during development
+func (p *DatabaseServiceProvider) Boot(app *foundation.Application) {
+	database.StrictLazyLoading = config.AppConfig != nil && config.AppConfig.IsDevelopment()
+}
@@ -0,0 +1,29 @@
+package providers
+
+import (
+	"mygola/app/models"
+	"mygola/app/observers"
+	"mygola/pkg/cache"
+	"mygola/pkg/database"
+	"mygola/pkg/foundation"
+)
+
+type ObserverServiceProvider struct{}
+
+func NewObserverServiceProvider() *ObserverServiceProvider {
+	return &ObserverServiceProvider{}
+}
+
+// Bind the observer registry the ORM reports model events to
+func (p *ObserverServiceProvider) Register(app *foundation.Application) {
+	app.Bind((*database.Observers)(nil), database.DefaultObservers)
+}
+
+// Register model observers
+func (p *ObserverServiceProvider) Boot(app *foundation.Application) {
+	registry := app.Make((*database.Observers)(nil)).(*database.Observers)
+
+	if appCache, ok := app.Make((*cache.Cache)(nil)).(cache.Cache); ok {
+		registry.Observe(&models.Product{}, observers.NewProductObserver(appCache))
+	}
+}
@@ -0,0 +1,28 @@
+package providers
+
+import (
+	appseeds "mygola/database/seeds"
+	"mygola/pkg/database/seeds"
+	"mygola/pkg/foundation"
+)
+
+type SeederServiceProvider struct{}
+
+func NewSeederServiceProvider() *SeederServiceProvider {
+	return &SeederServiceProvider{}
+}
+
+// Bind the registry db:seed runs seeders from
+func (p *SeederServiceProvider) Register(app *foundation.Application) {
+	app.Bind((*seeds.Registry)(nil), seeds.DefaultRegistry)
+}
+
+// Register database seeders; order between them comes from DependsOn
+func (p *SeederServiceProvider) Boot(app *foundation.Application) {
+	registry := app.Make((*seeds.Registry)(nil)).(*seeds.Registry)
+
+	registry.Register(
+		appseeds.NewUsersTableSeeder(),
+		appseeds.NewPostsTableSeeder(),
+	)
+}
@@ -0,0 +1,23 @@
+package providers
+
+import (
+	"mygola/database"
+	"mygola/pkg/foundation"
+	"mygola/pkg/validation"
+)
+
+type ValidationServiceProvider struct{}
+
+func NewValidationServiceProvider() *ValidationServiceProvider {
+	return &ValidationServiceProvider{}
+}
+
+// Nothing to bind, the rules live in the validation registry
+func (p *ValidationServiceProvider) Register(app *foundation.Application) {}
+
+// Point the unique and exists rules at the default connection
+func (p *ValidationServiceProvider) Boot(app *foundation.Application) {
+	if database.DB != nil {
+		validation.SetPresenceVerifier(validation.NewGormPresenceVerifier(database.DB))
+	}
+}
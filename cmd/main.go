@@ -1,27 +1,32 @@
 // main.go
-package cmd
+package main
 
 import (
+	"bufio"
 	"database/sql"
 	"fmt"
 	"log"
 	"mygola/app/providers"
 	"mygola/config"
+	dbconn "mygola/database"
+	_ "mygola/database/migrations"
 	"mygola/pkg/cache"
 	"mygola/pkg/database"
+	"mygola/pkg/database/seeds"
 	"mygola/pkg/foundation"
+	"mygola/pkg/gola"
 	"mygola/pkg/routing"
 	"mygola/pkg/schedule"
 	"mygola/pkg/session"
 	"mygola/pkg/view"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"text/template"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/spf13/cobra"
 )
 
@@ -45,14 +50,17 @@ var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run all pending migrations",
 	Run: func(cmd *cobra.Command, args []string) {
-		db := connectDB()
-		defer db.Close()
-		
-		migrator := database.NewMigrator(db)
+		pretend, _ := cmd.Flags().GetBool("pretend")
+		migrator := newMigrator(cmd).Pretend(pretend)
+		if !pretend && !confirmProduction(cmd) {
+			return
+		}
 		if err := migrator.RunMigrations("database/migrations"); err != nil {
 			log.Fatal("Migration failed:", err)
 		}
-		log.Println("✅ Migrations completed successfully")
+		if !pretend {
+			log.Println("✅ Migrations completed successfully")
+		}
 	},
 }
 
@@ -60,14 +68,130 @@ var rollbackCmd = &cobra.Command{
 	Use:   "migrate:rollback",
 	Short: "Rollback last batch of migrations",
 	Run: func(cmd *cobra.Command, args []string) {
-		db := connectDB()
-		defer db.Close()
-		
-		migrator := database.NewMigrator(db)
-		if err := migrator.RollbackMigrations("database/migrations"); err != nil {
+		pretend, _ := cmd.Flags().GetBool("pretend")
+		step, _ := cmd.Flags().GetInt("step")
+		batch, _ := cmd.Flags().GetInt("batch")
+		migrator := newMigrator(cmd).Pretend(pretend)
+		if !pretend && !confirmProduction(cmd) {
+			return
+		}
+
+		var err error
+		switch {
+		case step > 0:
+			err = migrator.RollbackSteps("database/migrations", step)
+		case batch > 0:
+			err = migrator.RollbackBatch("database/migrations", batch)
+		default:
+			err = migrator.RollbackMigrations("database/migrations")
+		}
+		if err != nil {
 			log.Fatal("Rollback failed:", err)
 		}
-		log.Println("✅ Rollback completed successfully")
+		if !pretend {
+			log.Println("✅ Rollback completed successfully")
+		}
+	},
+}
+
+var resetCmd = &cobra.Command{
+	Use:   "migrate:reset",
+	Short: "Rollback all migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator(cmd)
+		if !confirmProduction(cmd) {
+			return
+		}
+		if err := migrator.Reset("database/migrations"); err != nil {
+			log.Fatal("Reset failed:", err)
+		}
+		log.Println("✅ Reset completed successfully")
+	},
+}
+
+var refreshCmd = &cobra.Command{
+	Use:   "migrate:refresh",
+	Short: "Rollback all migrations and run them again",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator(cmd)
+		if !confirmProduction(cmd) {
+			return
+		}
+		if err := migrator.Refresh("database/migrations"); err != nil {
+			log.Fatal("Refresh failed:", err)
+		}
+		seedIfRequested(cmd)
+		log.Println("✅ Refresh completed successfully")
+	},
+}
+
+var freshCmd = &cobra.Command{
+	Use:   "migrate:fresh",
+	Short: "Drop all tables and run all migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator(cmd)
+		if !confirmProduction(cmd) {
+			return
+		}
+		if err := migrator.Fresh("database/migrations"); err != nil {
+			log.Fatal("Fresh failed:", err)
+		}
+		seedIfRequested(cmd)
+		log.Println("✅ Fresh completed successfully")
+	},
+}
+
+var dbSeedCmd = &cobra.Command{
+	Use:   "db:seed",
+	Short: "Run the registered database seeders",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("database")
+		db, dialect := connectDB(name)
+		if !confirmProduction(cmd) {
+			return
+		}
+		classes, _ := cmd.Flags().GetStringSlice("class")
+		rerun, _ := cmd.Flags().GetBool("rerun")
+		if err := runSeeders(db, dialect, rerun, classes...); err != nil {
+			log.Fatal("Seeding failed:", err)
+		}
+	},
+}
+
+var schemaDumpCmd = &cobra.Command{
+	Use:   "schema:dump",
+	Short: "Dump the database schema to database/schema/<connection>.sql",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator(cmd)
+		name := connectionName(cmd)
+		// Pruning deletes migration files, so ask like migrate:fresh does
+		prune, _ := cmd.Flags().GetBool("prune")
+		if prune && !confirmProduction(cmd) {
+			return
+		}
+
+		dump, err := dumpSchema(migrator, name)
+		if err != nil {
+			log.Fatal("Schema dump failed:", err)
+		}
+		path := schemaDumpPath(name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal("Failed to create schema directory:", err)
+		}
+		if err := os.WriteFile(path, []byte(dump), 0644); err != nil {
+			log.Fatal("Failed to write schema dump:", err)
+		}
+		log.Printf("✅ Schema dumped to %s", path)
+
+		if prune {
+			pruned, err := migrator.PruneMigrations("database/migrations")
+			if err != nil {
+				log.Fatal("Pruning migrations failed:", err)
+			}
+			for _, file := range pruned {
+				log.Printf("Pruned %s", file)
+			}
+		}
 	},
 }
 
@@ -75,10 +199,7 @@ var statusCmd = &cobra.Command{
 	Use:   "migrate:status",
 	Short: "Show migration status",
 	Run: func(cmd *cobra.Command, args []string) {
-		db := connectDB()
-		defer db.Close()
-		
-		migrator := database.NewMigrator(db)
+		migrator := newMigrator(cmd)
 		if err := showMigrationStatus(migrator); err != nil {
 			log.Fatal(err)
 		}
@@ -180,9 +301,14 @@ func main() {
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(refreshCmd)
+	rootCmd.AddCommand(freshCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(schemaDumpCmd)
+	rootCmd.AddCommand(dbSeedCmd)
 	rootCmd.AddCommand(makeCmd)
-	
+
 	// Add subcommands to make command
 	makeCmd.AddCommand(makeControllerCmd)
 	makeCmd.AddCommand(makeModelCmd)
@@ -192,8 +318,23 @@ func main() {
 	makeCmd.AddCommand(makeRequestCmd)
 	makeCmd.AddCommand(makeSeedCmd)
 	makeCmd.AddCommand(makeViewCmd)
-	
+
+	// Connection used by the migrate commands
+	rootCmd.PersistentFlags().String("database", "", "Database connection to use (defaults to database.default)")
+
 	// Add flags to make commands
+	migrateCmd.Flags().Bool("pretend", false, "Print the SQL without running it")
+	rollbackCmd.Flags().Bool("pretend", false, "Print the SQL without running it")
+	rollbackCmd.Flags().Int("step", 0, "Number of migrations to roll back")
+	rollbackCmd.Flags().Int("batch", 0, "Batch number to roll back")
+	refreshCmd.Flags().Bool("seed", false, "Seed the database afterwards")
+	freshCmd.Flags().Bool("seed", false, "Seed the database afterwards")
+	schemaDumpCmd.Flags().Bool("prune", false, "Delete the migrations the dump covers")
+	dbSeedCmd.Flags().StringSlice("class", nil, "Seeder to run with its dependencies, e.g. --class=ProductSeeder")
+	dbSeedCmd.Flags().Bool("rerun", false, "Run seeders again even if they ran before")
+	for _, cmd := range []*cobra.Command{migrateCmd, rollbackCmd, resetCmd, refreshCmd, freshCmd, dbSeedCmd, schemaDumpCmd} {
+		cmd.Flags().Bool("force", false, "Run without asking in production")
+	}
 	makeControllerCmd.Flags().BoolP("resource", "r", false, "Create a resource controller")
 	makeModelCmd.Flags().BoolP("migration", "m", false, "Create a migration for the model")
 
@@ -205,49 +346,165 @@ func main() {
 // ------------------------
 // Helper Functions
 // ------------------------
-func connectDB() *sql.DB {
-	cfg, err := config.LoadConfig("config.yaml")
-	if err != nil {
-		log.Fatal("Failed to load config:", err)
+
+// connectDB opens a configured connection, the default one when name is
+// empty, and returns it with its SQL dialect
+func connectDB(name string) (*sql.DB, database.Dialect) {
+	config.LoadConfig("config.yaml")
+	if name == "" {
+		name = config.AppConfig.Database.Default
 	}
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Name)
 
-	db, err := sql.Open("mysql", dsn)
+	conn, err := dbconn.Connection(name)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	db, err := conn.DB()
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	
+
 	// Test the connection
 	if err := db.Ping(); err != nil {
 		log.Fatal("Failed to ping database:", err)
 	}
-	
-	return db
+
+	return db, database.DialectFor(conn.Dialector.Name())
+}
+
+// newMigrator connects to the --database connection
+func newMigrator(cmd *cobra.Command) *database.Migrator {
+	name, _ := cmd.Flags().GetString("database")
+	db, dialect := connectDB(name)
+	return database.NewMigrator(db).UseDialect(dialect).UseSchemaDump(schemaDumpPath(connectionName(cmd)))
+}
+
+// connectionName is the --database connection or the default one
+func connectionName(cmd *cobra.Command) string {
+	if name, _ := cmd.Flags().GetString("database"); name != "" {
+		return name
+	}
+	return config.AppConfig.Database.Default
 }
 
-func startServer() {
-	cfg, err := config.LoadConfig("config.yaml")
+func schemaDumpPath(connection string) string {
+	return filepath.Join("database", "schema", connection+".sql")
+}
+
+// dumpSchema returns the schema of a connection followed by the rows of its
+// migrations table. MySQL and SQLite are read from the catalog, Postgres is
+// dumped with pg_dump, which must be on PATH; SQL Server is not supported.
+func dumpSchema(migrator *database.Migrator, name string) (string, error) {
+	conn := config.AppConfig.Database.Connections[name]
+	driver := conn.Driver
+	if driver == "" {
+		driver = name
+	}
+
+	var ddl string
+	var err error
+	if database.DialectFor(driver).Name() == "postgres" {
+		ddl, err = pgDump(conn)
+	} else {
+		ddl, err = migrator.SchemaSQL()
+	}
+	if err != nil {
+		return "", err
+	}
+	rows, err := migrator.MigrationRowsSQL()
 	if err != nil {
-		log.Fatal("Failed to load config:", err)
+		return "", err
 	}
 
-	db := connectDB()
-	defer db.Close()
+	header := fmt.Sprintf("-- Schema of the %s connection, dumped %s by schema:dump.\n"+
+		"-- migrate loads it into an empty database, then runs newer migrations.\n\n",
+		name, time.Now().Format("2006-01-02 15:04:05"))
+	return header + ddl + "\n" + rows, nil
+}
 
-	router := routing.NewRouter()
+// pgDump runs pg_dump against the connection's host, port, user and
+// database, passing the password in PGPASSWORD. Nothing else from the
+// connection reaches it: pg_dump negotiates SSL with its own default
+// (sslmode=prefer, or PGSSLMODE) rather than the sslmode=disable the app
+// connects with, and it dumps every schema the user can read, not only the
+// search_path. Set PGSSLMODE or use a ~/.pgpass file where that matters.
+func pgDump(conn config.Connection) (string, error) {
+	dump := exec.Command("pg_dump", "--schema-only", "--no-owner", "--no-privileges",
+		"--exclude-table=migrations", "-h", conn.Host, "-p", conn.Port, "-U", conn.Username, conn.Database)
+	dump.Env = append(os.Environ(), "PGPASSWORD="+conn.Password)
+	out, err := dump.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("pg_dump: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("pg_dump: %v", err)
+	}
+
+	// Drop psql meta-commands such as \restrict, and the empty search_path,
+	// which would outlive the load on a pooled connection
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "\\") || strings.HasPrefix(line, "SELECT pg_catalog.set_config('search_path'") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// confirmProduction asks before a migration command changes a production
+// database, unless --force is given
+func confirmProduction(cmd *cobra.Command) bool {
+	if force, _ := cmd.Flags().GetBool("force"); force || !config.AppConfig.IsProduction() {
+		return true
+	}
+
+	fmt.Printf("The application is in production. Do you really want to run %s? [y/N] ", cmd.Name())
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "y" || answer == "yes" {
+		return true
+	}
+	fmt.Println("Command cancelled.")
+	return false
+}
+
+// seedIfRequested runs all seeders again when --seed is given
+func seedIfRequested(cmd *cobra.Command) {
+	if seed, _ := cmd.Flags().GetBool("seed"); !seed {
+		return
+	}
+	name, _ := cmd.Flags().GetString("database")
+	db, dialect := connectDB(name)
+	if err := runSeeders(db, dialect, true); err != nil {
+		log.Fatal("Seeding failed:", err)
+	}
+}
+
+// runSeeders runs the seeders SeederServiceProvider registers, all of them
+// when no classes are given
+func runSeeders(db *sql.DB, dialect database.Dialect, rerun bool, classes ...string) error {
+	app := foundation.NewApplication()
+	app.Register(providers.NewSeederServiceProvider())
+	app.Boot()
+
+	registry := app.Make((*seeds.Registry)(nil)).(*seeds.Registry)
+	return seeds.NewRunner(db, dialect).UseRegistry(registry).Rerun(rerun).Run(classes...)
+}
+
+func startServer() {
+	db, _ := connectDB("")
+	defer dbconn.Close()
+
+	// Template engine
+	templateEngine := view.NewTemplateEngine("resources/views", "app")
+	ctx := &gola.Context{TemplateEngine: templateEngine}
+	router := routing.NewRouter(ctx)
 
 	// Session manager
 	sessionStore := session.NewMemoryStore()
 	sessionManager := session.NewManager(sessionStore, "mygola_session")
 
-	// Template engine
-	templateEngine := view.NewTemplateEngine("views", "app")
-
 	// Cache
 	appCache := cache.NewMemoryCache()
 
@@ -265,31 +522,33 @@ func startServer() {
 	app.Bind((*view.TemplateEngine)(nil), templateEngine)
 	app.Bind((*cache.Cache)(nil), appCache)
 
-	app.Register(providers.NewRouteServiceProvider(router))
+	app.Register(providers.NewRouteServiceProvider(router, templateEngine))
+	app.Register(providers.NewObserverServiceProvider())
 	app.Boot()
 
 	// Middleware
-	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			sess, err := sessionManager.Start(w, r)
+	router.Use(func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			sess, err := sessionManager.Start(ctx.Writer, ctx.Request)
 			if err != nil {
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				ctx.Error(500, "Internal Server Error")
 				return
 			}
-			next(w, r)
+			ctx.UseSession(sess)
+			next(ctx)
 			sess.Save()
 		}
 	})
 
-	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("%s %s", r.Method, r.URL.Path)
-			next(w, r)
+	router.Use(func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			log.Printf("%s %s", ctx.Request.Method, ctx.Request.URL.Path)
+			next(ctx)
 		}
 	})
 
 	// Start server
-	serverAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	serverAddr := fmt.Sprintf("%s:%d", config.AppConfig.Server.Host, config.AppConfig.Server.Port)
 	url := fmt.Sprintf("http://%s", serverAddr)
 	log.Printf("🚀 Server running at %s", url)
 	log.Fatal(http.ListenAndServe(serverAddr, router))
@@ -299,40 +558,19 @@ func startServer() {
 // Migration Status Helper
 // ------------------------
 func showMigrationStatus(migrator *database.Migrator) error {
-	if err := migrator.CreateMigrationsTable(); err != nil {
-		return fmt.Errorf("failed to create migrations table: %v", err)
-	}
-
-	runMigrations, err := migrator.GetRunMigrations()
-	if err != nil {
-		return fmt.Errorf("failed to get run migrations: %v", err)
-	}
-
-	migrationFiles, err := migrator.GetMigrationFiles("database/migrations")
+	statuses, err := migrator.Status("database/migrations")
 	if err != nil {
-		return fmt.Errorf("failed to get migration files: %v", err)
+		return err
 	}
-
-	pendingMigrations := migrator.GetPendingMigrations(migrationFiles, runMigrations)
-
-	fmt.Printf("Migrations Status:\n")
-	fmt.Printf("  Run: %d\n", len(runMigrations))
-	fmt.Printf("  Pending: %d\n", len(pendingMigrations))
-
-	if len(runMigrations) > 0 {
-		fmt.Println("\nRun Migrations:")
-		for _, migration := range runMigrations {
-			fmt.Printf("  %s (Batch: %d)\n", migration.Name, migration.Batch)
-		}
+	if len(statuses) == 0 {
+		fmt.Println("No migrations found.")
+		return nil
 	}
 
-	if len(pendingMigrations) > 0 {
-		fmt.Println("\nPending Migrations:")
-		for _, migration := range pendingMigrations {
-			fmt.Printf("  %s\n", migration)
-		}
+	fmt.Println("Migrations Status:")
+	for _, status := range statuses {
+		fmt.Printf("  %s\n", status)
 	}
-
 	return nil
 }
 
@@ -745,62 +983,68 @@ func (p *{{.Name}}Provider) Boot(app *foundation.Application) {
 const requestTemplate = `package {{.Package}}
 
 import (
-	"net/http"
-	"mygola/pkg/validation"
+	"mygola/pkg/gola"
 )
 
+// {{.Name}}Request is validated before the handler runs when the route uses
+// routing.Form:
+//
+//	router.Post("/path", routing.Form(controller.Store))
+//	func (c *Controller) Store(ctx *gola.Context, req *requests.{{.Name}}Request)
 type {{.Name}}Request struct {
-	// Add your form fields here
+	// Add your form fields here, e.g.
+	// Title string ` + "`" + `json:"title" validate:"required|max:255"` + "`" + `
+	// Email string ` + "`" + `json:"email" validate:"required|email"` + "`" + `
 }
 
-func (r *{{.Name}}Request) Validate(req *http.Request) (*validation.Validator, error) {
-	// Parse form data
-	if err := req.ParseForm(); err != nil {
-		return nil, err
-	}
-	
-	// Create validator
-	validator := validation.NewValidator(map[string]interface{}{
-		// Add your form data here
-	})
-	
-	// Define validation rules
-	rules := map[string]string{
-		// Add your validation rules here
-	}
-	
-	// Validate
-	if !validator.Validate(rules) {
-		return validator, validation.ErrValidationFailed
-	}
-	
-	return validator, nil
+// Rules adds rules on top of the validate tags
+func (r *{{.Name}}Request) Rules() map[string]string {
+	return map[string]string{}
+}
+
+// Messages overrides messages, keyed "rule" or "field.rule"
+func (r *{{.Name}}Request) Messages() map[string]string {
+	return map[string]string{}
+}
+
+// Authorize decides whether the current user may make this request
+func (r *{{.Name}}Request) Authorize(ctx *gola.Context) bool {
+	return true
+}
+
+// Prepare normalizes the input before validation
+func (r *{{.Name}}Request) Prepare() {
+	// e.g. trim and lowercase an email before it is checked
 }
 `
 
 const seedTemplate = `package {{.Package}}
 
 import (
-	"database/sql"
-	"fmt"
+	"mygola/pkg/database"
 )
 
-type {{.Name}}Seeder struct {}
+// {{.Name}}Seeder runs with db:seed once registered in
+// app/providers/seeder_service_provider.go
+type {{.Name}}Seeder struct{}
 
 func New{{.Name}}Seeder() *{{.Name}}Seeder {
 	return &{{.Name}}Seeder{}
 }
 
-func (s *{{.Name}}Seeder) Run(db *sql.DB) error {
-	// Implement your seeder logic here
-	fmt.Println("Running {{.Name}} seeder...")
-	
+// DependsOn names the seeders that must run first, e.g. "UsersTableSeeder"
+func (s *{{.Name}}Seeder) DependsOn() []string {
+	return nil
+}
+
+// Run seeds inside a transaction; it runs once unless db:seed --rerun
+func (s *{{.Name}}Seeder) Run(db database.Executor, dialect database.Dialect) error {
 	// Example:
-	// _, err := db.Exec("INSERT INTO table (column) VALUES (?)", "value")
+	// _, err := db.Exec("INSERT INTO table (column) VALUES ("+dialect.Placeholder(1)+")", "value")
 	// if err != nil {
 	//     return fmt.Errorf("failed to seed: %v", err)
 	// }
-	
+
 	return nil
 }
 `
@@ -845,4 +1089,5 @@ const viewShowTemplate = `{{define "content"}}
     <button type="submit">Delete</button>
 </form>
 <a href="/{{.Name}}">Back to list</a>
-{{end}}
\ No newline at end of file
+{{end}}
+`
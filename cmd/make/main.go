@@ -649,62 +649,68 @@ func (p *{{.Name}}Provider) Boot(app *foundation.Application) {
 const requestTemplate = `package {{.Package}}
 
 import (
-	"net/http"
-	"mygola/pkg/validation"
+	"mygola/pkg/gola"
 )
 
+// {{.Name}}Request is validated before the handler runs when the route uses
+// routing.Form:
+//
+//	router.Post("/path", routing.Form(controller.Store))
+//	func (c *Controller) Store(ctx *gola.Context, req *requests.{{.Name}}Request)
 type {{.Name}}Request struct {
-	// Add your form fields here
+	// Add your form fields here, e.g.
+	// Title string ` + "`" + `json:"title" validate:"required|max:255"` + "`" + `
+	// Email string ` + "`" + `json:"email" validate:"required|email"` + "`" + `
 }
 
-func (r *{{.Name}}Request) Validate(req *http.Request) (*validation.Validator, error) {
-	// Parse form data
-	if err := req.ParseForm(); err != nil {
-		return nil, err
-	}
-	
-	// Create validator
-	validator := validation.NewValidator(map[string]interface{}{
-		// Add your form data here
-	})
-	
-	// Define validation rules
-	rules := map[string]string{
-		// Add your validation rules here
-	}
-	
-	// Validate
-	if !validator.Validate(rules) {
-		return validator, validation.ErrValidationFailed
-	}
-	
-	return validator, nil
+// Rules adds rules on top of the validate tags
+func (r *{{.Name}}Request) Rules() map[string]string {
+	return map[string]string{}
+}
+
+// Messages overrides messages, keyed "rule" or "field.rule"
+func (r *{{.Name}}Request) Messages() map[string]string {
+	return map[string]string{}
+}
+
+// Authorize decides whether the current user may make this request
+func (r *{{.Name}}Request) Authorize(ctx *gola.Context) bool {
+	return true
+}
+
+// Prepare normalizes the input before validation
+func (r *{{.Name}}Request) Prepare() {
+	// e.g. trim and lowercase an email before it is checked
 }
 `
 
 const seedTemplate = `package {{.Package}}
 
 import (
-	"database/sql"
-	"fmt"
+	"mygola/pkg/database"
 )
 
-type {{.Name}}Seeder struct {}
+// {{.Name}}Seeder runs with db:seed once registered in
+// app/providers/seeder_service_provider.go
+type {{.Name}}Seeder struct{}
 
 func New{{.Name}}Seeder() *{{.Name}}Seeder {
 	return &{{.Name}}Seeder{}
 }
 
-func (s *{{.Name}}Seeder) Run(db *sql.DB) error {
-	// Implement your seeder logic here
-	fmt.Println("Running {{.Name}} seeder...")
-	
+// DependsOn names the seeders that must run first, e.g. "UsersTableSeeder"
+func (s *{{.Name}}Seeder) DependsOn() []string {
+	return nil
+}
+
+// Run seeds inside a transaction; it runs once unless db:seed --rerun
+func (s *{{.Name}}Seeder) Run(db database.Executor, dialect database.Dialect) error {
 	// Example:
-	// _, err := db.Exec("INSERT INTO table (column) VALUES (?)", "value")
+	// _, err := db.Exec("INSERT INTO table (column) VALUES ("+dialect.Placeholder(1)+")", "value")
 	// if err != nil {
 	//     return fmt.Errorf("failed to seed: %v", err)
 	// }
-	
+
 	return nil
 }
 `
@@ -2,14 +2,21 @@
 package main
 
 import (
+	"bufio"
 	"database/sql"
 	"fmt"
 	"log"
+	"mygola/app/providers"
 	"mygola/config"
+	dbconn "mygola/database"
+	_ "mygola/database/migrations"
 	"mygola/pkg/database"
+	"mygola/pkg/database/seeds"
+	"mygola/pkg/foundation"
 	"os"
-
-	_ "github.com/go-sql-driver/mysql"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 func main() {
@@ -19,27 +26,38 @@ func main() {
 	}
 
 	// Load configuration
-	config, err := config.LoadConfig("config.yaml")
+	config.LoadConfig("config.yaml")
+
+	// Initialize database, MIGRATE_CONNECTION picks another connection
+	name := os.Getenv("MIGRATE_CONNECTION")
+	if name == "" {
+		name = config.AppConfig.Database.Default
+	}
+	conn, err := dbconn.Connection(name)
 	if err != nil {
-		log.Fatal("Failed to load config:", err)
+		log.Fatal("Failed to connect to database:", err)
 	}
+	defer dbconn.Close()
 
-	// Initialize database
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
-		config.Database.User,
-		config.Database.Password,
-		config.Database.Host,
-		config.Database.Port,
-		config.Database.Name)
-
-	db, err := sql.Open("mysql", dsn)
+	db, err := conn.DB()
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
 
-	// Create migrator
-	migrator := database.NewMigrator(db)
+	// Create migrator, --pretend prints the SQL instead of running it
+	dialect := database.DialectFor(conn.Dialector.Name())
+	migrator := database.NewMigrator(db).UseDialect(dialect).
+		UseSchemaDump(filepath.Join("database", "schema", name+".sql"))
+	pretend := hasFlag("--pretend")
+	migrator.Pretend(pretend)
+
+	// Destructive commands ask first in production, --force skips the question
+	switch os.Args[1] {
+	case "migrate", "rollback", "reset", "refresh", "fresh", "seed":
+		if !pretend && !confirmProduction() {
+			return
+		}
+	}
 
 	// Handle commands
 	switch os.Args[1] {
@@ -47,13 +65,54 @@ func main() {
 		if err := migrator.RunMigrations("database/migrations"); err != nil {
 			log.Fatal("Migration failed:", err)
 		}
-		fmt.Println("Migrations completed successfully")
+		if !pretend {
+			fmt.Println("Migrations completed successfully")
+		}
 
 	case "rollback":
-		if err := migrator.RollbackMigrations("database/migrations"); err != nil {
+		var err error
+		if step := intFlag("--step"); step > 0 {
+			err = migrator.RollbackSteps("database/migrations", step)
+		} else if batch := intFlag("--batch"); batch > 0 {
+			err = migrator.RollbackBatch("database/migrations", batch)
+		} else {
+			err = migrator.RollbackMigrations("database/migrations")
+		}
+		if err != nil {
 			log.Fatal("Rollback failed:", err)
 		}
-		fmt.Println("Rollback completed successfully")
+		if !pretend {
+			fmt.Println("Rollback completed successfully")
+		}
+
+	case "reset":
+		if err := migrator.Reset("database/migrations"); err != nil {
+			log.Fatal("Reset failed:", err)
+		}
+		fmt.Println("Reset completed successfully")
+
+	case "refresh":
+		if err := migrator.Refresh("database/migrations"); err != nil {
+			log.Fatal("Refresh failed:", err)
+		}
+		seedIfRequested(db, dialect)
+		fmt.Println("Refresh completed successfully")
+
+	case "fresh":
+		if err := migrator.Fresh("database/migrations"); err != nil {
+			log.Fatal("Fresh failed:", err)
+		}
+		seedIfRequested(db, dialect)
+		fmt.Println("Fresh completed successfully")
+
+	case "seed":
+		var classes []string
+		if class := stringFlag("--class"); class != "" {
+			classes = strings.Split(class, ",")
+		}
+		if err := runSeeders(db, dialect, hasFlag("--rerun"), classes...); err != nil {
+			log.Fatal("Seeding failed:", err)
+		}
 
 	case "status":
 		if err := showMigrationStatus(migrator); err != nil {
@@ -75,53 +134,108 @@ func main() {
 }
 
 func printHelp() {
-	fmt.Println("Usage: migrate <command>")
+	fmt.Println("Usage: migrate <command> [options]")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  migrate    Run all pending migrations")
 	fmt.Println("  rollback   Rollback the last batch of migrations")
+	fmt.Println("  reset      Rollback all migrations")
+	fmt.Println("  refresh    Rollback all migrations and run them again")
+	fmt.Println("  fresh      Drop all tables and run all migrations")
+	fmt.Println("  seed       Run the registered database seeders")
 	fmt.Println("  status     Show migration status")
 	fmt.Println("  make <name> Create a new migration (use the make command instead)")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --pretend  Print the SQL of migrate/rollback without running it")
+	fmt.Println("  --step=N   Rollback the last N migrations")
+	fmt.Println("  --batch=N  Rollback batch N")
+	fmt.Println("  --seed     Seed the database after refresh/fresh")
+	fmt.Println("  --class=X  Run seeder X (comma separated) and what it depends on")
+	fmt.Println("  --rerun    Run seeders again even if they ran before")
+	fmt.Println("  --force    Do not ask for confirmation in production")
 }
 
-func showMigrationStatus(migrator *database.Migrator) error {
-	// Ensure migrations table exists
-	if err := migrator.CreateMigrationsTable(); err != nil {
-		return err
+// intFlag reads a --name=N argument, 0 when absent
+func intFlag(flag string) int {
+	for _, arg := range os.Args[2:] {
+		if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				log.Fatalf("%s expects a number, got %q", flag, value)
+			}
+			return n
+		}
 	}
+	return 0
+}
 
-	// Get run migrations
-	runMigrations, err := migrator.GetRunMigrations()
-	if err != nil {
-		return err
+func confirmProduction() bool {
+	if hasFlag("--force") || !config.AppConfig.IsProduction() {
+		return true
 	}
 
-	// Get all migration files
-	migrationFiles, err := migrator.GetMigrationFiles("database/migrations")
-	if err != nil {
-		return err
+	fmt.Printf("The application is in production. Do you really want to run %s? [y/N] ", os.Args[1])
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "y" || answer == "yes" {
+		return true
+	}
+	fmt.Println("Command cancelled.")
+	return false
+}
+
+func seedIfRequested(db *sql.DB, dialect database.Dialect) {
+	if !hasFlag("--seed") {
+		return
+	}
+	if err := runSeeders(db, dialect, true); err != nil {
+		log.Fatal("Seeding failed:", err)
 	}
+}
 
-	// Get pending migrations
-	pendingMigrations := migrator.GetPendingMigrations(migrationFiles, runMigrations)
+// runSeeders runs the seeders SeederServiceProvider registers
+func runSeeders(db *sql.DB, dialect database.Dialect, rerun bool, classes ...string) error {
+	app := foundation.NewApplication()
+	app.Register(providers.NewSeederServiceProvider())
+	app.Boot()
 
-	fmt.Printf("Migrations Status:\n")
-	fmt.Printf("  Run: %d\n", len(runMigrations))
-	fmt.Printf("  Pending: %d\n", len(pendingMigrations))
+	registry := app.Make((*seeds.Registry)(nil)).(*seeds.Registry)
+	return seeds.NewRunner(db, dialect).UseRegistry(registry).Rerun(rerun).Run(classes...)
+}
 
-	if len(runMigrations) > 0 {
-		fmt.Println("\nRun Migrations:")
-		for _, migration := range runMigrations {
-			fmt.Printf("  %s (Batch: %d)\n", migration.Name, migration.Batch)
+// stringFlag reads a --name=value argument
+func stringFlag(flag string) string {
+	for _, arg := range os.Args[2:] {
+		if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+			return value
 		}
 	}
+	return ""
+}
 
-	if len(pendingMigrations) > 0 {
-		fmt.Println("\nPending Migrations:")
-		for _, migration := range pendingMigrations {
-			fmt.Printf("  %s\n", migration)
+func hasFlag(flag string) bool {
+	for _, arg := range os.Args[2:] {
+		if arg == flag {
+			return true
 		}
 	}
+	return false
+}
 
+func showMigrationStatus(migrator *database.Migrator) error {
+	statuses, err := migrator.Status("database/migrations")
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		fmt.Println("No migrations found.")
+		return nil
+	}
+
+	fmt.Println("Migrations Status:")
+	for _, status := range statuses {
+		fmt.Printf("  %s\n", status)
+	}
 	return nil
 }
@@ -3,14 +3,20 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
+	App struct {
+		Name string `yaml:"name"`
+		// Env is local, testing or production; APP_ENV overrides it
+		Env string `yaml:"env"`
+	} `yaml:"app"`
 	Database struct {
 		Default     string
-		Connections map[string]map[string]string
+		Connections map[string]Connection
 	}
 	Server struct {
 		Host string `yaml:"host"`
@@ -18,8 +24,46 @@ type Config struct {
 	} `yaml:"server"`
 }
 
+// Connection is one entry of database.connections. Driver defaults to the
+// connection name, so the mysql, pgsql, sqlite, sqlserver and mongodb
+// entries need not set it.
+type Connection struct {
+	Driver   string `yaml:"driver"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Database string `yaml:"database"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Path     string `yaml:"path"` // sqlite
+	URI      string `yaml:"uri"`  // mongodb
+
+	// Read lists replica hosts ("host" or "host:port") that share the
+	// credentials above and serve SELECTs
+	Read []string `yaml:"read"`
+	// Sticky keeps a request's reads on the primary for this long after
+	// it wrote, so it sees its own changes despite replication lag; see
+	// database.WithSticky
+	Sticky time.Duration `yaml:"sticky"`
+
+	MaxOpen     int           `yaml:"max_open"`
+	MaxIdle     int           `yaml:"max_idle"`
+	MaxLifetime time.Duration `yaml:"max_lifetime"`
+	MaxIdleTime time.Duration `yaml:"max_idle_time"`
+}
+
 var AppConfig *Config
 
+// IsProduction reports whether the app runs with env production
+func (c *Config) IsProduction() bool {
+	return c.App.Env == "production"
+}
+
+// IsDevelopment reports whether the app runs on a developer machine, with
+// env local or development
+func (c *Config) IsDevelopment() bool {
+	return c.App.Env == "local" || c.App.Env == "development"
+}
+
 func LoadConfig(path string) {
 	file, err := os.ReadFile(path)
 	if err != nil {
@@ -31,6 +75,9 @@ func LoadConfig(path string) {
 	if err != nil {
 		log.Fatalf("YAML parse error: %v", err)
 	}
+	if env := os.Getenv("APP_ENV"); env != "" {
+		cfg.App.Env = env
+	}
 
 	AppConfig = &cfg
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"sync"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -16,37 +18,27 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"mygola/config"
+	orm "mygola/pkg/database"
 )
 
 var DB *gorm.DB
 var MongoClient *mongo.Client
 
+var (
+	connections   = make(map[string]*gorm.DB)
+	connectionsMu sync.Mutex
+)
+
 func InitDB() {
 	cfg := config.AppConfig
 	dbConn := cfg.Database.Default
-	conn := cfg.Database.Connections[dbConn]
 
-	var err error
-
-	switch dbConn {
-	case "mysql":
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			conn["username"], conn["password"], conn["host"], conn["port"], conn["database"])
-		DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	case "pgsql":
-		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-			conn["host"], conn["username"], conn["password"], conn["database"], conn["port"])
-		DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	case "sqlite":
-		DB, err = gorm.Open(sqlite.Open(conn["path"]), &gorm.Config{})
-	case "sqlserver":
-		dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%s?database=%s",
-			conn["username"], conn["password"], conn["host"], conn["port"], conn["database"])
-		DB, err = gorm.Open(sqlserver.Open(dsn), &gorm.Config{})
-	case "mongodb":
+	if driverOf(dbConn, cfg.Database.Connections[dbConn]) == "mongodb" {
+		conn := cfg.Database.Connections[dbConn]
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		clientOptions := options.Client().ApplyURI(conn["uri"])
+		clientOptions := options.Client().ApplyURI(conn.URI)
+		var err error
 		MongoClient, err = mongo.Connect(ctx, clientOptions)
 		if err != nil {
 			log.Fatal(err)
@@ -56,15 +48,194 @@ func InitDB() {
 			log.Fatal("MongoDB ping failed:", err)
 		}
 		fmt.Println("MongoDB connected successfully")
-	default:
-		log.Fatal("Invalid DB connection")
+		return
 	}
 
-	if dbConn != "mongodb" && err != nil {
+	var err error
+	DB, err = Connection(dbConn)
+	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Println(dbConn, "connected successfully")
+}
+
+// Connection returns the pool of a connection from config.yaml, opening it
+// on first use:
+//
+//	db, err := database.Connection("pgsql")
+//	if err != nil {
+//		return err
+//	}
+//	var total int64
+//	db.Table("orders").Count(&total)
+//
+// Connections with read replicas send SELECTs to the replicas in turn and
+// everything else, transactions included, to the primary.
+func Connection(name string) (*gorm.DB, error) {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+
+	if db, ok := connections[name]; ok {
+		return db, nil
+	}
+
+	if config.AppConfig == nil {
+		return nil, fmt.Errorf("database: config not loaded")
+	}
+	conn, ok := config.AppConfig.Database.Connections[name]
+	if !ok {
+		return nil, fmt.Errorf("database: connection %q is not configured", name)
+	}
+
+	db, err := open(name, conn)
+	if err != nil {
+		return nil, fmt.Errorf("database: connection %q: %v", name, err)
+	}
+	connections[name] = db
+	return db, nil
+}
+
+// ORM returns the query builder on a connection, the default connection
+// when name is empty, with the connection's SQL dialect. On a connection
+// with read replicas its SELECTs go to the replicas in turn, without
+// stickiness, and writes and transactions to the primary; read a row you
+// just wrote with OnPrimary:
+//
+//	db, err := database.ORM("")
+//	err = db.Create(&post)
+//	err = db.OnPrimary().Find(&post, post.ID)
+func ORM(name string) (*orm.ORM, error) {
+	if name == "" && config.AppConfig != nil {
+		name = config.AppConfig.Database.Default
+	}
+	db, err := Connection(name)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("database: connection %q: %v", name, err)
+	}
+	o := orm.NewORM(sqlDB).UseDialect(orm.DialectFor(db.Dialector.Name()))
+	if r, ok := db.Config.ConnPool.(*resolver); ok {
+		o.UseReader(replicaReader{r})
+	}
+	return o, nil
+}
+
+// Close closes every opened connection
+func Close() error {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+
+	var firstErr error
+	for name, db := range connections {
+		if r, ok := db.Config.ConnPool.(*resolver); ok {
+			if err := r.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		} else if sqlDB, err := db.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		delete(connections, name)
+	}
+	return firstErr
+}
+
+func open(name string, conn config.Connection) (*gorm.DB, error) {
+	driver := driverOf(name, conn)
+
+	dialector, err := dialectorFor(driver, conn)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	primary, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	configurePool(primary, conn)
+
+	if len(conn.Read) == 0 {
+		return db, nil
+	}
+
+	r := &resolver{primary: primary, sticky: conn.Sticky}
+	for _, host := range conn.Read {
+		if err := r.addReplica(driver, conn, host); err != nil {
+			r.Close()
+			return nil, fmt.Errorf("read replica %s: %v", host, err)
+		}
+	}
+
+	db.Config.ConnPool = r
+	db.Statement.ConnPool = r
+	return db, nil
+}
+
+func (r *resolver) addReplica(driver string, conn config.Connection, host string) error {
+	replica := conn
+	replica.Host, replica.Port = splitHost(host, conn.Port)
+	if driver == "sqlite" {
+		replica.Path = host
+	}
+
+	dialector, err := dialectorFor(driver, replica)
+	if err != nil {
+		return err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	configurePool(sqlDB, conn)
+	r.replicas = append(r.replicas, sqlDB)
+	return nil
+}
+
+func driverOf(name string, conn config.Connection) string {
+	if conn.Driver != "" {
+		return conn.Driver
+	}
+	return name
+}
+
+func dialectorFor(driver string, conn config.Connection) (gorm.Dialector, error) {
+	switch driver {
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			conn.Username, conn.Password, conn.Host, conn.Port, conn.Database)
+		return mysql.Open(dsn), nil
+	case "pgsql", "postgres":
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+			conn.Host, conn.Username, conn.Password, conn.Database, conn.Port)
+		return postgres.Open(dsn), nil
+	case "sqlite":
+		return sqlite.Open(conn.Path), nil
+	case "sqlserver":
+		dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%s?database=%s",
+			conn.Username, conn.Password, conn.Host, conn.Port, conn.Database)
+		return sqlserver.Open(dsn), nil
+	case "mongodb":
+		return nil, fmt.Errorf("mongodb is not a SQL connection, use MongoClient")
+	}
+	return nil, fmt.Errorf("unsupported driver %q", driver)
+}
 
-	if dbConn != "mongodb" {
-		fmt.Println(dbConn, "connected successfully")
+// splitHost reads "host" or "host:port", keeping the primary's port when
+// the replica does not set one
+func splitHost(host, port string) (string, string) {
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		return h, p
 	}
+	return host, port
 }
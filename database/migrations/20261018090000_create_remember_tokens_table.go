@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"mygola/pkg/database"
+	"mygola/pkg/database/schema"
+)
+
+func init() {
+	database.RegisterMigration(database.GoMigration{
+		Name: "20261018090000_create_remember_tokens_table",
+		Up: func(exec database.Executor, dialect database.Dialect) error {
+			return schema.NewBuilder(exec, dialect).Create("remember_tokens", func(t *schema.Blueprint) {
+				t.Increments("id")
+				t.String("selector", 32).Unique()
+				t.Char("validator_hash", 64)
+				t.Integer("user_id").Index()
+				t.DateTime("expires_at")
+				t.Timestamp("created_at").Nullable().UseCurrent()
+			})
+		},
+		Down: func(exec database.Executor, dialect database.Dialect) error {
+			return schema.NewBuilder(exec, dialect).DropIfExists("remember_tokens")
+		},
+	})
+}
@@ -0,0 +1,5 @@
+// Package migrations holds the application's migrations. SQL migrations are
+// <timestamp>_<name>_up.sql / _down.sql pairs; Go migrations are files of
+// this package that call database.RegisterMigration from init. The migrate
+// commands import the package, so both kinds run in timestamp order.
+package migrations
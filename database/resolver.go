@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mygola/config"
+	"mygola/pkg/gola"
+)
+
+// readQuery matches statements that can go to a replica; locking reads
+// stay on the primary
+var (
+	readQuery    = regexp.MustCompile(`(?is)^\s*(SELECT|WITH)\b`)
+	lockingQuery = regexp.MustCompile(`(?i)\bFOR\s+(UPDATE|SHARE)\b|\bLOCK\s+IN\s+SHARE\s+MODE\b`)
+)
+
+// resolver is the gorm connection pool of a connection with read replicas
+type resolver struct {
+	primary  *sql.DB
+	replicas []*sql.DB
+	sticky   time.Duration
+
+	next atomic.Uint64
+}
+
+// stickyKey holds the *stickyWrites of a request in its context
+type stickyKey struct{}
+
+type stickyWrites struct {
+	lastWrite atomic.Int64 // unix nanoseconds
+}
+
+// WithSticky scopes read stickiness to ctx: after a write through a query
+// run with ctx, or a context derived from it, its reads stay on the primary
+// for the connection's sticky duration. Writes elsewhere do not affect it,
+// and queries without such a context always read from the replicas.
+//
+//	db.WithContext(database.WithSticky(ctx)).Create(&post)
+func WithSticky(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyKey{}, &stickyWrites{})
+}
+
+// StickyReads is router middleware giving every request its own sticky
+// scope; pass ctx.Request.Context() to gorm's WithContext in handlers
+func StickyReads(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(ctx *gola.Context) {
+		ctx.Request = ctx.Request.WithContext(WithSticky(ctx.Request.Context()))
+		next(ctx)
+	}
+}
+
+// replicaReader is the reader of an ORM on a connection with replicas. It
+// has no request context, so writes do not make its reads sticky; see
+// orm.OnPrimary.
+type replicaReader struct {
+	r *resolver
+}
+
+func (rr replicaReader) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return rr.r.ExecContext(context.Background(), query, args...)
+}
+
+func (rr replicaReader) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return rr.r.QueryContext(context.Background(), query, args...)
+}
+
+func (rr replicaReader) QueryRow(query string, args ...interface{}) *sql.Row {
+	return rr.r.QueryRowContext(context.Background(), query, args...)
+}
+
+var (
+	_ gorm.ConnPool       = (*resolver)(nil)
+	_ gorm.TxBeginner     = (*resolver)(nil)
+	_ gorm.GetDBConnector = (*resolver)(nil)
+)
+
+// reader picks the pool for a read: a replica in turn, or the primary
+// while a recent write of the same context is sticky
+func (r *resolver) reader(ctx context.Context, query string) *sql.DB {
+	if !readQuery.MatchString(query) || lockingQuery.MatchString(query) {
+		r.wrote(ctx)
+		return r.primary
+	}
+	if writes, ok := ctx.Value(stickyKey{}).(*stickyWrites); ok && r.sticky > 0 {
+		if last := writes.lastWrite.Load(); last != 0 && time.Since(time.Unix(0, last)) < r.sticky {
+			return r.primary
+		}
+	}
+	return r.replicas[r.next.Add(1)%uint64(len(r.replicas))]
+}
+
+func (r *resolver) wrote(ctx context.Context) {
+	if writes, ok := ctx.Value(stickyKey{}).(*stickyWrites); ok && r.sticky > 0 {
+		writes.lastWrite.Store(time.Now().UnixNano())
+	}
+}
+
+func (r *resolver) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.primary.PrepareContext(ctx, query)
+}
+
+func (r *resolver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	r.wrote(ctx)
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// QueryContext also carries writes, e.g. INSERT ... RETURNING on postgres
+func (r *resolver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.reader(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (r *resolver) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.reader(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx keeps the whole transaction on the primary
+func (r *resolver) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	r.wrote(ctx)
+	return r.primary.BeginTx(ctx, opts)
+}
+
+// GetDBConn makes gorm's DB() return the primary
+func (r *resolver) GetDBConn() (*sql.DB, error) {
+	return r.primary, nil
+}
+
+func (r *resolver) Close() error {
+	errs := []error{r.primary.Close()}
+	for _, replica := range r.replicas {
+		errs = append(errs, replica.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// configurePool applies the pool settings of a connection
+func configurePool(db *sql.DB, conn config.Connection) {
+	if conn.MaxOpen > 0 {
+		db.SetMaxOpenConns(conn.MaxOpen)
+	}
+	if conn.MaxIdle > 0 {
+		db.SetMaxIdleConns(conn.MaxIdle)
+	}
+	if conn.MaxLifetime > 0 {
+		db.SetConnMaxLifetime(conn.MaxLifetime)
+	}
+	if conn.MaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(conn.MaxIdleTime)
+	}
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"mygola/config"
+	orm "mygola/pkg/database"
+)
+
+// useReplicatedSQLite configures a sqlite connection whose replica is a
+// second file, so every query shows which side answered it
+func useReplicatedSQLite(t *testing.T, sticky time.Duration) string {
+	t.Helper()
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+	for path, title := range map[string]string{primary: "primary", replica: "replica"} {
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = db.Exec("CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT); INSERT INTO posts (title) VALUES ('" + title + "')")
+		db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved := config.AppConfig
+	config.AppConfig = &config.Config{}
+	config.AppConfig.Database.Default = "sqlite"
+	config.AppConfig.Database.Connections = map[string]config.Connection{
+		"sqlite": {Driver: "sqlite", Path: primary, Read: []string{replica}, Sticky: sticky},
+	}
+	t.Cleanup(func() {
+		Close()
+		config.AppConfig = saved
+	})
+	return "sqlite"
+}
+
+type post struct {
+	ID    int    `db:"id"`
+	Title string `db:"title"`
+}
+
+func (post) TableName() string { return "posts" }
+
+func TestORMReadsFromReplicas(t *testing.T) {
+	useReplicatedSQLite(t, 0)
+	db, err := ORM("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var titles []string
+	if err := db.Table("posts").Pluck("title", &titles); err != nil || len(titles) != 1 || titles[0] != "replica" {
+		t.Fatalf("read: %v, %v; want the replica", titles, err)
+	}
+
+	// Writes go to the primary, which OnPrimary reads
+	if err := db.Create(&post{Title: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := db.Table("posts").Count(); err != nil || n != 1 {
+		t.Fatalf("replica count = %d, %v; the write reached the replica", n, err)
+	}
+	if n, err := db.OnPrimary().Table("posts").Count(); err != nil || n != 2 {
+		t.Fatalf("primary count = %d, %v", n, err)
+	}
+
+	// Transactions read what they wrote
+	err = db.Transaction(func(tx *orm.ORM) error {
+		n, err := tx.Table("posts").Count()
+		if err == nil && n != 2 {
+			t.Errorf("count in transaction = %d, want the primary's 2", n)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Update diffs against the primary's row, not the replica's
+	p := post{ID: 2, Title: "edited"}
+	if err := db.Update(&p); err != nil {
+		t.Fatalf("update of a row only the primary has: %v", err)
+	}
+}
+
+func TestStickyReadsFollowTheContext(t *testing.T) {
+	name := useReplicatedSQLite(t, time.Minute)
+	db, err := Connection(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	title := func(ctx context.Context) string {
+		var p post
+		if err := db.WithContext(ctx).Table("posts").Order("id").First(&p).Error; err != nil {
+			t.Fatal(err)
+		}
+		return p.Title
+	}
+
+	ctx := WithSticky(context.Background())
+	if got := title(ctx); got != "replica" {
+		t.Fatalf("read before writing came from %s", got)
+	}
+	if err := db.WithContext(ctx).Exec("INSERT INTO posts (title) VALUES ('new')").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := title(ctx); got != "primary" {
+		t.Fatalf("read after writing came from %s, want the primary", got)
+	}
+	if got := title(WithSticky(context.Background())); got != "replica" {
+		t.Fatalf("another request's read came from %s, want the replica", got)
+	}
+}
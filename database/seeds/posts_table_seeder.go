@@ -0,0 +1,43 @@
+package seeds
+
+import (
+	"fmt"
+
+	"mygola/pkg/database"
+)
+
+type PostsTableSeeder struct{}
+
+func NewPostsTableSeeder() *PostsTableSeeder {
+	return &PostsTableSeeder{}
+}
+
+// Posts belong to the seeded users
+func (s *PostsTableSeeder) DependsOn() []string {
+	return []string{"UsersTableSeeder"}
+}
+
+func (s *PostsTableSeeder) Run(db database.Executor, dialect database.Dialect) error {
+	// Insert sample posts
+	posts := []struct {
+		ID      string
+		Title   string
+		Content string
+		UserID  string
+	}{
+		{"1", "First Post", "This is the first post", "1"},
+		{"2", "Second Post", "This is the second post", "1"},
+		{"3", "Third Post", "This is the third post", "2"},
+	}
+
+	query := fmt.Sprintf("INSERT INTO posts (id, title, content, user_id) VALUES (%s, %s, %s, %s)",
+		dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3), dialect.Placeholder(4))
+	for _, post := range posts {
+		_, err := db.Exec(query, post.ID, post.Title, post.Content, post.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to seed posts: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package seeds
+
+import (
+	"fmt"
+
+	"mygola/pkg/database"
+)
+
+type UsersTableSeeder struct{}
+
+func NewUsersTableSeeder() *UsersTableSeeder {
+	return &UsersTableSeeder{}
+}
+
+func (s *UsersTableSeeder) Run(db database.Executor, dialect database.Dialect) error {
+	// Insert sample users
+	users := []struct {
+		ID       string
+		Name     string
+		Email    string
+		Password string
+	}{
+		{"1", "John Doe", "john@example.com", "password123"},
+		{"2", "Jane Smith", "jane@example.com", "password123"},
+		{"3", "Bob Johnson", "bob@example.com", "password123"},
+	}
+
+	query := fmt.Sprintf("INSERT INTO users (id, name, email, password) VALUES (%s, %s, %s, %s)",
+		dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3), dialect.Placeholder(4))
+	for _, user := range users {
+		_, err := db.Exec(query, user.ID, user.Name, user.Email, user.Password)
+		if err != nil {
+			return fmt.Errorf("failed to seed users: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -56,6 +56,9 @@ func startServer() {
 
 	// Register route service provider
 	app.Register(providers.NewRouteServiceProvider(router, templateEngine))
+	app.Register(providers.NewDatabaseServiceProvider())
+	app.Register(providers.NewObserverServiceProvider())
+	app.Register(providers.NewValidationServiceProvider())
 	app.Boot()
 
 	// Middleware
@@ -66,11 +69,15 @@ func startServer() {
 				ctx.Error(500, "Internal Server Error")
 				return
 			}
+			ctx.UseSession(sess)
 			next(ctx)
 			sess.Save()
 		}
 	})
 
+	// Reads after a write stay on the primary within the request
+	router.Use(database.StickyReads)
+
 	router.Use(func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
 		return func(ctx *gola.Context) {
 			log.Printf("%s %s", ctx.Request.Method, ctx.Request.URL.Path)
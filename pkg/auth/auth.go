@@ -3,9 +3,11 @@ package auth
 
 import (
 	"crypto/rand"
+	"database/sql"
 	"encoding/base64"
 	"errors"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -18,6 +20,14 @@ type User interface {
 type Auth struct {
 	store    UserStore
 	sessions map[string]Session
+	mu       sync.RWMutex
+
+	// Remember-me support, enabled through UseRememberTokens
+	tokens           TokenStore
+	RememberDuration time.Duration
+
+	// Optional brute-force protection, enabled through UseThrottler
+	throttle *Throttler
 }
 
 type Session struct {
@@ -25,6 +35,8 @@ type Session struct {
 	ExpiresAt time.Time
 }
 
+// UserStore loads users; FindByEmail returns ErrUserNotFound (or
+// sql.ErrNoRows) when no user has the email
 type UserStore interface {
 	FindByID(id int) (User, error)
 	FindByEmail(email string) (User, error)
@@ -32,13 +44,25 @@ type UserStore interface {
 
 func NewAuth(store UserStore) *Auth {
 	return &Auth{
-		store:    store,
-		sessions: make(map[string]Session),
+		store:            store,
+		sessions:         make(map[string]Session),
+		RememberDuration: 30 * 24 * time.Hour,
 	}
 }
 
+// Attempt checks the credentials. With a throttler it is throttled per
+// email; AttemptFrom also keys on the client IP.
 func (a *Auth) Attempt(email, password string) (User, error) {
+	return a.attempt(email, "", password)
+}
+
+// verify checks the credentials; an unknown email is invalid credentials
+// too, so callers cannot tell which emails exist
+func (a *Auth) verify(email, password string) (User, error) {
 	user, err := a.store.FindByEmail(email)
+	if IsUserNotFound(err) {
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -59,10 +83,12 @@ func (a *Auth) Login(user User, w http.ResponseWriter) error {
 	}
 
 	sessionToken := base64.StdEncoding.EncodeToString(token)
+	a.mu.Lock()
 	a.sessions[sessionToken] = Session{
 		UserID:    user.GetID(),
 		ExpiresAt: time.Now().Add(24 * time.Hour),
 	}
+	a.mu.Unlock()
 
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session",
@@ -80,7 +106,9 @@ func (a *Auth) User(r *http.Request) (User, error) {
 		return nil, err
 	}
 
+	a.mu.RLock()
 	session, exists := a.sessions[cookie.Value]
+	a.mu.RUnlock()
 	if !exists || session.ExpiresAt.Before(time.Now()) {
 		return nil, ErrUnauthenticated
 	}
@@ -91,4 +119,11 @@ func (a *Auth) User(r *http.Request) (User, error) {
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUnauthenticated    = errors.New("unauthenticated")
+	ErrUserNotFound       = errors.New("user not found")
 )
+
+// IsUserNotFound reports whether a UserStore error means there is no such
+// user, as opposed to the store failing
+func IsUserNotFound(err error) bool {
+	return errors.Is(err, ErrUserNotFound) || errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,50 @@
+// pkg/auth/middleware.go
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"mygola/pkg/gola"
+)
+
+// userKey holds the authenticated User in the request context
+type userKey struct{}
+
+// Middleware is the session guard as router middleware. It resolves the
+// user with Authenticate, so a request whose session expired is logged in
+// again from its remember cookie, and stores the user for UserFrom. Guests
+// are redirected to loginURL, or get a 401 when they want JSON:
+//
+//	router.Get("/dashboard", dashboard.Show, authenticator.Middleware("/login"))
+func (a *Auth) Middleware(loginURL string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			user, err := a.Authenticate(ctx.Writer, ctx.Request)
+			if err != nil && !errors.Is(err, ErrUnauthenticated) && !IsUserNotFound(err) {
+				log.Printf("auth: %v", err)
+				ctx.Error(http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+			if err != nil {
+				if ctx.WantsJSON() {
+					ctx.JSON(http.StatusUnauthorized, map[string]string{"message": "Unauthenticated."})
+				} else {
+					ctx.Redirect(http.StatusFound, loginURL)
+				}
+				return
+			}
+
+			ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), userKey{}, user))
+			next(ctx)
+		}
+	}
+}
+
+// UserFrom returns the user Middleware authenticated for the request
+func UserFrom(r *http.Request) (User, bool) {
+	user, ok := r.Context().Value(userKey{}).(User)
+	return user, ok
+}
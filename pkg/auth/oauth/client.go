@@ -0,0 +1,110 @@
+// pkg/auth/oauth/client.go
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"mygola/pkg/session"
+)
+
+// Client drives the authorization-code flow, keeping the state and PKCE
+// verifier in the user's session between redirect and callback.
+type Client struct {
+	registry *Registry
+}
+
+func NewClient(registry *Registry) *Client {
+	return &Client{registry: registry}
+}
+
+// Redirect sends the user to the provider's consent screen
+func (c *Client) Redirect(w http.ResponseWriter, r *http.Request, sess session.Session, providerName string) error {
+	provider, err := c.registry.Get(providerName)
+	if err != nil {
+		return err
+	}
+
+	state, err := randomString(32)
+	if err != nil {
+		return err
+	}
+	verifier, err := randomString(48)
+	if err != nil {
+		return err
+	}
+
+	sess.Set(stateKey(providerName), state)
+	sess.Set(verifierKey(providerName), verifier)
+	if err := sess.Save(); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, provider.AuthCodeURL(state, codeChallenge(verifier)), http.StatusFound)
+	return nil
+}
+
+// Callback validates the returned state, exchanges the code and fetches the
+// provider profile. The stored state is consumed, and saved, even when
+// validation fails, so a callback URL cannot be replayed.
+func (c *Client) Callback(r *http.Request, sess session.Session, providerName string) (*UserInfo, error) {
+	provider, err := c.registry.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, _ := sess.Get(stateKey(providerName)).(string)
+	verifier, _ := sess.Get(verifierKey(providerName)).(string)
+	sess.Delete(stateKey(providerName))
+	sess.Delete(verifierKey(providerName))
+	if err := sess.Save(); err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		return nil, fmt.Errorf("%s: authorization denied: %s", providerName, errCode)
+	}
+
+	state := query.Get("state")
+	if expected == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expected)) != 1 {
+		return nil, ErrInvalidState
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return nil, fmt.Errorf("%s: callback is missing the authorization code", providerName)
+	}
+
+	token, err := provider.Exchange(r.Context(), code, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.User(r.Context(), token)
+}
+
+func stateKey(provider string) string {
+	return "oauth_state_" + provider
+}
+
+func verifierKey(provider string) string {
+	return "oauth_verifier_" + provider
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
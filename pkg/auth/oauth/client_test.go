@@ -0,0 +1,157 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"mygola/pkg/session"
+)
+
+// testProvider is an OpenID provider on httptest that checks the PKCE
+// verifier against the challenge of the authorization URL
+type testProvider struct {
+	*httptest.Server
+	challenge string
+	exchanges int
+}
+
+func newTestProvider(t *testing.T) (*testProvider, *Client) {
+	t.Helper()
+	p := &testProvider{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.exchanges++
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access", "token_type": "Bearer", "expires_in": 3600})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sub": "42", "email": "ada@example.com", "email_verified": true, "name": "Ada",
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+
+	registry := NewRegistry()
+	registry.Register(newOIDC("test", p.URL+"/authorize", p.URL+"/token", p.URL+"/userinfo", Config{
+		ClientID:    "client",
+		RedirectURL: "http://app.test/auth/test/callback",
+	}))
+	return p, NewClient(registry)
+}
+
+// redirect runs Redirect and returns the session cookie and the
+// authorization URL parameters
+func redirect(t *testing.T, p *testProvider, client *Client, manager *session.Manager) (*http.Cookie, url.Values) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/auth/test", nil)
+	sess, err := manager.Start(w, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Redirect(w, r, sess, "test"); err != nil {
+		t.Fatalf("Redirect: %v", err)
+	}
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := location.Query()
+	p.challenge = params.Get("code_challenge")
+	return w.Result().Cookies()[0], params
+}
+
+// callback runs Callback in a new request carrying the session cookie, so
+// the state has to come from the session store
+func callback(t *testing.T, client *Client, manager *session.Manager, cookie *http.Cookie, query url.Values) (*UserInfo, error) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/auth/test/callback?"+query.Encode(), nil)
+	r.AddCookie(cookie)
+	sess, err := manager.Start(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.Callback(r, sess, "test")
+}
+
+func TestCallbackExchangesCodeWithPKCE(t *testing.T) {
+	p, client := newTestProvider(t)
+	manager := session.NewManager(session.NewMemoryStore(), "sid")
+
+	cookie, params := redirect(t, p, client, manager)
+	if params.Get("code_challenge_method") != "S256" || p.challenge == "" {
+		t.Fatalf("authorization URL lacks a S256 challenge: %v", params)
+	}
+
+	info, err := callback(t, client, manager, cookie, url.Values{"state": {params.Get("state")}, "code": {"good-code"}})
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if info.ID != "42" || info.Email != "ada@example.com" || !info.EmailVerified {
+		t.Fatalf("unexpected profile: %+v", info)
+	}
+}
+
+func TestCallbackRejectsStateMismatch(t *testing.T) {
+	p, client := newTestProvider(t)
+	manager := session.NewManager(session.NewMemoryStore(), "sid")
+
+	cookie, params := redirect(t, p, client, manager)
+	_, err := callback(t, client, manager, cookie, url.Values{"state": {"forged"}, "code": {"good-code"}})
+	if !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("forged state: got %v, want ErrInvalidState", err)
+	}
+
+	// The failed callback consumed the state, so the genuine one is refused too
+	_, err = callback(t, client, manager, cookie, url.Values{"state": {params.Get("state")}, "code": {"good-code"}})
+	if !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("replayed state: got %v, want ErrInvalidState", err)
+	}
+	if p.exchanges != 0 {
+		t.Fatalf("code exchanged %d times despite invalid state", p.exchanges)
+	}
+}
+
+func TestCallbackCannotBeReplayed(t *testing.T) {
+	p, client := newTestProvider(t)
+	manager := session.NewManager(session.NewMemoryStore(), "sid")
+
+	cookie, params := redirect(t, p, client, manager)
+	query := url.Values{"state": {params.Get("state")}, "code": {"good-code"}}
+	if _, err := callback(t, client, manager, cookie, query); err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if _, err := callback(t, client, manager, cookie, query); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("replay: got %v, want ErrInvalidState", err)
+	}
+}
+
+func TestCallbackFailsWithWrongVerifier(t *testing.T) {
+	p, client := newTestProvider(t)
+	manager := session.NewManager(session.NewMemoryStore(), "sid")
+
+	cookie, params := redirect(t, p, client, manager)
+	p.challenge = "challenge-of-another-login"
+	_, err := callback(t, client, manager, cookie, url.Values{"state": {params.Get("state")}, "code": {"good-code"}})
+	if err == nil {
+		t.Fatal("exchange succeeded with a verifier that does not match the challenge")
+	}
+}
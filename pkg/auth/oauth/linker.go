@@ -0,0 +1,76 @@
+// pkg/auth/oauth/linker.go
+package oauth
+
+import (
+	"errors"
+	"strings"
+
+	"mygola/pkg/auth"
+)
+
+var (
+	ErrAccountNotFound  = errors.New("linked account not found")
+	ErrEmailNotVerified = errors.New("provider email is not verified")
+)
+
+// AccountStore persists the provider identities linked to users
+type AccountStore interface {
+	// FindByProvider returns ErrAccountNotFound when nothing is linked
+	FindByProvider(provider, providerID string) (auth.User, error)
+	Link(user auth.User, provider, providerID string) error
+	CreateFromProvider(info *UserInfo) (auth.User, error)
+}
+
+// Linker maps a provider profile to an auth.User
+type Linker struct {
+	users    auth.UserStore
+	accounts AccountStore
+}
+
+func NewLinker(users auth.UserStore, accounts AccountStore) *Linker {
+	return &Linker{
+		users:    users,
+		accounts: accounts,
+	}
+}
+
+// Resolve returns the user already linked to the identity, links an existing
+// user with the same verified email, or creates a new user.
+func (l *Linker) Resolve(info *UserInfo) (auth.User, error) {
+	user, err := l.accounts.FindByProvider(info.Provider, info.ID)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, ErrAccountNotFound) {
+		return nil, err
+	}
+
+	if info.Email != "" {
+		existing, err := l.users.FindByEmail(strings.ToLower(info.Email))
+		// Only a missing user may lead to a new one; a failing store
+		// would otherwise create duplicate accounts
+		if err != nil && !auth.IsUserNotFound(err) {
+			return nil, err
+		}
+		if err == nil && existing != nil {
+			// Linking on an unverified address would let anyone claim an
+			// account by registering its email at the provider
+			if !info.EmailVerified {
+				return nil, ErrEmailNotVerified
+			}
+			if err := l.accounts.Link(existing, info.Provider, info.ID); err != nil {
+				return nil, err
+			}
+			return existing, nil
+		}
+	}
+
+	user, err = l.accounts.CreateFromProvider(info)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.accounts.Link(user, info.Provider, info.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
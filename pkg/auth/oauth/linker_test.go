@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"errors"
+	"testing"
+
+	"mygola/pkg/auth"
+)
+
+type testUser struct {
+	id    int
+	email string
+}
+
+func (u *testUser) GetID() int          { return u.id }
+func (u *testUser) GetEmail() string    { return u.email }
+func (u *testUser) GetPassword() string { return "" }
+
+type testUsers struct {
+	byEmail map[string]*testUser
+	err     error
+}
+
+func (s *testUsers) FindByID(id int) (auth.User, error) { return nil, auth.ErrUserNotFound }
+
+func (s *testUsers) FindByEmail(email string) (auth.User, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if user, ok := s.byEmail[email]; ok {
+		return user, nil
+	}
+	return nil, auth.ErrUserNotFound
+}
+
+type testAccounts struct {
+	links   map[string]auth.User
+	created int
+}
+
+func (s *testAccounts) FindByProvider(provider, providerID string) (auth.User, error) {
+	if user, ok := s.links[provider+":"+providerID]; ok {
+		return user, nil
+	}
+	return nil, ErrAccountNotFound
+}
+
+func (s *testAccounts) Link(user auth.User, provider, providerID string) error {
+	s.links[provider+":"+providerID] = user
+	return nil
+}
+
+func (s *testAccounts) CreateFromProvider(info *UserInfo) (auth.User, error) {
+	s.created++
+	return &testUser{id: 100 + s.created, email: info.Email}, nil
+}
+
+func TestLinkerResolve(t *testing.T) {
+	ada := &testUser{id: 1, email: "ada@example.com"}
+
+	tests := []struct {
+		name        string
+		info        UserInfo
+		linked      bool
+		storeErr    error
+		wantID      int
+		wantErr     error
+		wantCreated int
+	}{
+		{name: "linked identity", info: UserInfo{Provider: "github", ID: "7"}, linked: true, wantID: 1},
+		{name: "verified email links existing user", info: UserInfo{Provider: "github", ID: "7", Email: "Ada@example.com", EmailVerified: true}, wantID: 1},
+		{name: "unverified email is refused", info: UserInfo{Provider: "github", ID: "7", Email: "ada@example.com"}, wantErr: ErrEmailNotVerified},
+		{name: "unknown email creates user", info: UserInfo{Provider: "github", ID: "7", Email: "new@example.com", EmailVerified: true}, wantID: 101, wantCreated: 1},
+		{name: "store failure creates nothing", info: UserInfo{Provider: "github", ID: "7", Email: "ada@example.com", EmailVerified: true}, storeErr: errors.New("db timeout"), wantErr: errors.New("db timeout")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &testUsers{byEmail: map[string]*testUser{ada.email: ada}, err: tt.storeErr}
+			accounts := &testAccounts{links: map[string]auth.User{}}
+			if tt.linked {
+				accounts.links["github:7"] = ada
+			}
+
+			user, err := NewLinker(users, accounts).Resolve(&tt.info)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			} else if user.GetID() != tt.wantID {
+				t.Fatalf("got user %d, want %d", user.GetID(), tt.wantID)
+			}
+			if accounts.created != tt.wantCreated {
+				t.Fatalf("created %d users, want %d", accounts.created, tt.wantCreated)
+			}
+			if tt.wantErr == nil && accounts.links["github:7"] == nil {
+				t.Fatal("identity was not linked")
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// pkg/auth/oauth/providers.go
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ======================
+// GitHub
+// ======================
+
+type GitHub struct {
+	OAuth2
+	APIURL string
+}
+
+func NewGitHub(cfg Config) *GitHub {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+	return &GitHub{
+		OAuth2: OAuth2{
+			Config:   cfg,
+			AuthURL:  "https://github.com/login/oauth/authorize",
+			TokenURL: "https://github.com/login/oauth/access_token",
+		},
+		APIURL: "https://api.github.com",
+	}
+}
+
+func (p *GitHub) Name() string {
+	return "github"
+}
+
+func (p *GitHub) User(ctx context.Context, token *Token) (*UserInfo, error) {
+	var raw map[string]interface{}
+	if err := p.getJSON(ctx, p.APIURL+"/user", token, &raw); err != nil {
+		return nil, err
+	}
+
+	info := &UserInfo{
+		Provider:  p.Name(),
+		ID:        stringValue(raw["id"]),
+		Name:      stringValue(raw["name"]),
+		AvatarURL: stringValue(raw["avatar_url"]),
+		Raw:       raw,
+	}
+	if info.Name == "" {
+		info.Name = stringValue(raw["login"])
+	}
+
+	// The profile email may be hidden or unverified; the emails endpoint
+	// tells us which address is primary and verified.
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(ctx, p.APIURL+"/user/emails", token, &emails); err == nil {
+		for _, e := range emails {
+			if e.Primary {
+				info.Email = e.Email
+				info.EmailVerified = e.Verified
+				break
+			}
+		}
+	}
+	if info.Email == "" {
+		info.Email = stringValue(raw["email"])
+	}
+
+	return info, nil
+}
+
+// ======================
+// OpenID Connect (Google and generic issuers)
+// ======================
+
+type OIDC struct {
+	OAuth2
+	name        string
+	UserInfoURL string
+}
+
+// NewOIDC configures a provider from the issuer's discovery document
+func NewOIDC(ctx context.Context, name, issuer string, cfg Config) (*OIDC, error) {
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery failed: status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %v", err)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("oidc discovery failed: incomplete document from %s", issuer)
+	}
+
+	return newOIDC(name, doc.AuthorizationEndpoint, doc.TokenEndpoint, doc.UserinfoEndpoint, cfg), nil
+}
+
+// NewGoogle returns Google's OpenID Connect provider
+func NewGoogle(cfg Config) *OIDC {
+	return newOIDC("google",
+		"https://accounts.google.com/o/oauth2/v2/auth",
+		"https://oauth2.googleapis.com/token",
+		"https://openidconnect.googleapis.com/v1/userinfo",
+		cfg)
+}
+
+func newOIDC(name, authURL, tokenURL, userInfoURL string, cfg Config) *OIDC {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDC{
+		OAuth2: OAuth2{
+			Config:   cfg,
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+		},
+		name:        name,
+		UserInfoURL: userInfoURL,
+	}
+}
+
+func (p *OIDC) Name() string {
+	return p.name
+}
+
+func (p *OIDC) User(ctx context.Context, token *Token) (*UserInfo, error) {
+	var raw map[string]interface{}
+	if err := p.getJSON(ctx, p.UserInfoURL, token, &raw); err != nil {
+		return nil, err
+	}
+
+	info := &UserInfo{
+		Provider:  p.Name(),
+		ID:        stringValue(raw["sub"]),
+		Email:     stringValue(raw["email"]),
+		Name:      stringValue(raw["name"]),
+		AvatarURL: stringValue(raw["picture"]),
+		Raw:       raw,
+	}
+
+	// Some issuers send email_verified as the string "true"
+	switch v := raw["email_verified"].(type) {
+	case bool:
+		info.EmailVerified = v
+	case string:
+		info.EmailVerified = v == "true"
+	}
+
+	if info.ID == "" {
+		return nil, fmt.Errorf("%s: userinfo response has no subject", p.name)
+	}
+	return info, nil
+}
+
+func stringValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
+}
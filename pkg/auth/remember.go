@@ -0,0 +1,239 @@
+// pkg/auth/remember.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RememberCookieName is the cookie holding the "selector:validator" pair
+const RememberCookieName = "remember_token"
+
+var ErrTokenNotFound = errors.New("remember token not found")
+
+// ErrPasswordChangeUnsupported is returned by ChangePassword when the user
+// store does not implement PasswordUpdater
+var ErrPasswordChangeUnsupported = errors.New("user store cannot update passwords")
+
+// RememberToken is a persistent login token. Only the selector is stored in
+// plain text; the validator is kept as a SHA-256 hash so a leaked table
+// cannot be replayed as cookies.
+type RememberToken struct {
+	Selector      string
+	ValidatorHash string
+	UserID        int
+	ExpiresAt     time.Time
+}
+
+// TokenStore persists remember tokens
+type TokenStore interface {
+	Create(token RememberToken) error
+	FindBySelector(selector string) (*RememberToken, error)
+	Delete(selector string) error
+	DeleteForUser(userID int) error
+}
+
+// UseRememberTokens enables remember-me logins backed by the given store
+func (a *Auth) UseRememberTokens(tokens TokenStore) {
+	a.tokens = tokens
+}
+
+// LoginRemember logs the user in and, when remember is true, issues a
+// long-lived remember token next to the session cookie.
+func (a *Auth) LoginRemember(user User, w http.ResponseWriter, remember bool) error {
+	if err := a.Login(user, w); err != nil {
+		return err
+	}
+	if !remember || a.tokens == nil {
+		return nil
+	}
+	return a.issueRememberToken(user.GetID(), w)
+}
+
+// Authenticate is the session guard. It resolves the user from the session
+// cookie and, once that session has expired, re-authenticates from the
+// remember cookie, rotating the token and starting a fresh session.
+func (a *Auth) Authenticate(w http.ResponseWriter, r *http.Request) (User, error) {
+	user, err := a.User(r)
+	if err == nil {
+		return user, nil
+	}
+	if a.tokens == nil {
+		return nil, ErrUnauthenticated
+	}
+	return a.userFromRememberCookie(w, r)
+}
+
+// Logout ends the current session and revokes its remember token
+func (a *Auth) Logout(w http.ResponseWriter, r *http.Request) error {
+	if cookie, err := r.Cookie("session"); err == nil {
+		a.mu.Lock()
+		delete(a.sessions, cookie.Value)
+		a.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: "session", Value: "", MaxAge: -1, HttpOnly: true})
+
+	if a.tokens != nil {
+		if cookie, err := r.Cookie(RememberCookieName); err == nil {
+			selector, _, _ := strings.Cut(cookie.Value, ":")
+			if err := a.tokens.Delete(selector); err != nil && !errors.Is(err, ErrTokenNotFound) {
+				return err
+			}
+		}
+	}
+	clearRememberCookie(w)
+	return nil
+}
+
+// PasswordChanged revokes every remember token of the user, so devices that
+// were remembered with the old password have to log in again. ChangePassword
+// calls it; call it yourself when a password is changed some other way,
+// e.g. by a reset link.
+func (a *Auth) PasswordChanged(user User) error {
+	if a.tokens == nil {
+		return nil
+	}
+	return a.tokens.DeleteForUser(user.GetID())
+}
+
+// PasswordUpdater is implemented by user stores that can change passwords
+type PasswordUpdater interface {
+	UpdatePassword(user User, password string) error
+}
+
+// ChangePassword checks the current password, stores the new one and
+// revokes the user's remember tokens
+func (a *Auth) ChangePassword(user User, current, password string) error {
+	updater, ok := a.store.(PasswordUpdater)
+	if !ok {
+		return ErrPasswordChangeUnsupported
+	}
+
+	// In real implementation, use bcrypt to compare hashed passwords
+	if user.GetPassword() != current {
+		return ErrInvalidCredentials
+	}
+	if err := updater.UpdatePassword(user, password); err != nil {
+		return err
+	}
+	return a.PasswordChanged(user)
+}
+
+func (a *Auth) userFromRememberCookie(w http.ResponseWriter, r *http.Request) (User, error) {
+	cookie, err := r.Cookie(RememberCookieName)
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	selector, validator, ok := strings.Cut(cookie.Value, ":")
+	if !ok || selector == "" || validator == "" {
+		clearRememberCookie(w)
+		return nil, ErrUnauthenticated
+	}
+
+	token, err := a.tokens.FindBySelector(selector)
+	if err != nil {
+		clearRememberCookie(w)
+		if errors.Is(err, ErrTokenNotFound) {
+			return nil, ErrUnauthenticated
+		}
+		return nil, err
+	}
+
+	// Tokens are single use: whatever happens next, this one is spent
+	if err := a.tokens.Delete(selector); err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashValidator(validator)), []byte(token.ValidatorHash)) != 1 {
+		// A known selector with a wrong validator means the cookie was
+		// stolen or replayed, so drop every token the user has
+		clearRememberCookie(w)
+		if err := a.tokens.DeleteForUser(token.UserID); err != nil {
+			return nil, err
+		}
+		return nil, ErrUnauthenticated
+	}
+
+	if token.ExpiresAt.Before(time.Now()) {
+		clearRememberCookie(w)
+		return nil, ErrUnauthenticated
+	}
+
+	user, err := a.store.FindByID(token.UserID)
+	if err != nil {
+		clearRememberCookie(w)
+		return nil, err
+	}
+
+	if err := a.Login(user, w); err != nil {
+		return nil, err
+	}
+	if err := a.issueRememberToken(user.GetID(), w); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (a *Auth) issueRememberToken(userID int, w http.ResponseWriter) error {
+	selector, err := randomToken(12)
+	if err != nil {
+		return err
+	}
+	validator, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(a.RememberDuration)
+	err = a.tokens.Create(RememberToken{
+		Selector:      selector,
+		ValidatorHash: hashValidator(validator),
+		UserID:        userID,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     RememberCookieName,
+		Value:    selector + ":" + validator,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func clearRememberCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     RememberCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashValidator(validator string) string {
+	sum := sha256.Sum256([]byte(validator))
+	return hex.EncodeToString(sum[:])
+}
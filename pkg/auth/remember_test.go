@@ -0,0 +1,314 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mygola/pkg/gola"
+)
+
+type memoryUser struct {
+	id       int
+	email    string
+	password string
+}
+
+func (u *memoryUser) GetID() int          { return u.id }
+func (u *memoryUser) GetEmail() string    { return u.email }
+func (u *memoryUser) GetPassword() string { return u.password }
+
+type memoryUsers map[int]*memoryUser
+
+func (s memoryUsers) FindByID(id int) (User, error) {
+	if user, ok := s[id]; ok {
+		return user, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s memoryUsers) FindByEmail(email string) (User, error) {
+	for _, user := range s {
+		if user.email == email {
+			return user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s memoryUsers) UpdatePassword(user User, password string) error {
+	s[user.GetID()].password = password
+	return nil
+}
+
+// memoryTokens is a TokenStore in a map, keyed by selector
+type memoryTokens map[string]RememberToken
+
+func (s memoryTokens) Create(token RememberToken) error {
+	s[token.Selector] = token
+	return nil
+}
+
+func (s memoryTokens) FindBySelector(selector string) (*RememberToken, error) {
+	token, ok := s[selector]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return &token, nil
+}
+
+func (s memoryTokens) Delete(selector string) error {
+	delete(s, selector)
+	return nil
+}
+
+func (s memoryTokens) DeleteForUser(userID int) error {
+	for selector, token := range s {
+		if token.UserID == userID {
+			delete(s, selector)
+		}
+	}
+	return nil
+}
+
+func newRememberAuth() (*Auth, memoryTokens) {
+	a := NewAuth(memoryUsers{
+		1: {id: 1, email: "ada@example.com", password: "secret"},
+		2: {id: 2, email: "grace@example.com", password: "secret"},
+	})
+	tokens := memoryTokens{}
+	a.UseRememberTokens(tokens)
+	return a, tokens
+}
+
+// rememberCookie logs user in with remember-me, as from another device
+func rememberCookie(t *testing.T, a *Auth, id int) string {
+	t.Helper()
+	user, _ := a.store.FindByID(id)
+	w := httptest.NewRecorder()
+	if err := a.LoginRemember(user, w, true); err != nil {
+		t.Fatal(err)
+	}
+	return responseCookie(w, RememberCookieName)
+}
+
+func responseCookie(w *httptest.ResponseRecorder, name string) string {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func requestWith(cookies map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	for name, value := range cookies {
+		r.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	return r
+}
+
+func TestAuthenticateWithRememberCookie(t *testing.T) {
+	tests := []struct {
+		name string
+		// cookie returns the remember cookie sent, given the one issued to user 1
+		cookie     func(t *testing.T, a *Auth, tokens memoryTokens, issued string) string
+		wantUser   bool
+		wantTokens int // left in the store afterwards, user 2's included
+	}{
+		{
+			name:       "valid cookie rotates the token",
+			cookie:     func(t *testing.T, a *Auth, tokens memoryTokens, issued string) string { return issued },
+			wantUser:   true,
+			wantTokens: 2,
+		},
+		{
+			name:       "malformed cookie",
+			cookie:     func(t *testing.T, a *Auth, tokens memoryTokens, issued string) string { return "no-separator" },
+			wantTokens: 2,
+		},
+		{
+			name:       "unknown selector",
+			cookie:     func(t *testing.T, a *Auth, tokens memoryTokens, issued string) string { return "unknown:validator" },
+			wantTokens: 2,
+		},
+		{
+			name: "stolen selector with a forged validator revokes every token of the user",
+			cookie: func(t *testing.T, a *Auth, tokens memoryTokens, issued string) string {
+				rememberCookie(t, a, 1) // a second device of the same user
+				selector, _, _ := strings.Cut(issued, ":")
+				return selector + ":forged"
+			},
+			wantTokens: 1,
+		},
+		{
+			name: "expired token is spent",
+			cookie: func(t *testing.T, a *Auth, tokens memoryTokens, issued string) string {
+				selector, _, _ := strings.Cut(issued, ":")
+				token := tokens[selector]
+				token.ExpiresAt = time.Now().Add(-time.Minute)
+				tokens[selector] = token
+				return issued
+			},
+			wantTokens: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, tokens := newRememberAuth()
+			issued := rememberCookie(t, a, 1)
+			rememberCookie(t, a, 2)
+			sent := tt.cookie(t, a, tokens, issued)
+
+			w := httptest.NewRecorder()
+			user, err := a.Authenticate(w, requestWith(map[string]string{RememberCookieName: sent}))
+			if tt.wantUser {
+				if err != nil || user.GetID() != 1 {
+					t.Fatalf("Authenticate = %v, %v; want user 1", user, err)
+				}
+				rotated := responseCookie(w, RememberCookieName)
+				if rotated == "" || rotated == issued {
+					t.Fatalf("remember cookie was not rotated: %q", rotated)
+				}
+				if responseCookie(w, "session") == "" {
+					t.Fatal("no fresh session was started")
+				}
+			} else {
+				if err != ErrUnauthenticated {
+					t.Fatalf("Authenticate = %v, %v; want ErrUnauthenticated", user, err)
+				}
+				if cleared := responseCookie(w, RememberCookieName); cleared != "" {
+					t.Fatalf("remember cookie was not cleared: %q", cleared)
+				}
+			}
+			if len(tokens) != tt.wantTokens {
+				t.Fatalf("%d tokens left, want %d", len(tokens), tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestRotatedCookieCannotBeReplayed(t *testing.T) {
+	a, tokens := newRememberAuth()
+	issued := rememberCookie(t, a, 1)
+
+	w := httptest.NewRecorder()
+	if _, err := a.Authenticate(w, requestWith(map[string]string{RememberCookieName: issued})); err != nil {
+		t.Fatal(err)
+	}
+	rotated := responseCookie(w, RememberCookieName)
+
+	if _, err := a.Authenticate(httptest.NewRecorder(), requestWith(map[string]string{RememberCookieName: issued})); err != ErrUnauthenticated {
+		t.Fatalf("replayed cookie: got %v, want ErrUnauthenticated", err)
+	}
+	if _, err := a.Authenticate(httptest.NewRecorder(), requestWith(map[string]string{RememberCookieName: rotated})); err != nil {
+		t.Fatalf("rotated cookie: %v", err)
+	}
+	if len(tokens) != 1 {
+		t.Fatalf("%d tokens left, want the latest one", len(tokens))
+	}
+}
+
+func TestExpiredSessionFallsBackToRememberCookie(t *testing.T) {
+	a, _ := newRememberAuth()
+	user, _ := a.store.FindByID(1)
+	w := httptest.NewRecorder()
+	if err := a.LoginRemember(user, w, true); err != nil {
+		t.Fatal(err)
+	}
+	session, remember := responseCookie(w, "session"), responseCookie(w, RememberCookieName)
+
+	a.sessions[session] = Session{UserID: 1, ExpiresAt: time.Now().Add(-time.Second)}
+	w = httptest.NewRecorder()
+	got, err := a.Authenticate(w, requestWith(map[string]string{"session": session, RememberCookieName: remember}))
+	if err != nil || got.GetID() != 1 {
+		t.Fatalf("Authenticate after the session expired = %v, %v", got, err)
+	}
+	if renewed := responseCookie(w, "session"); renewed == "" || renewed == session {
+		t.Fatalf("session was not renewed: %q", renewed)
+	}
+}
+
+func TestChangePasswordRevokesRememberTokens(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    string
+		wantErr    error
+		wantTokens int
+	}{
+		{name: "correct password", current: "secret", wantTokens: 1},
+		{name: "wrong password", current: "guess", wantErr: ErrInvalidCredentials, wantTokens: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, tokens := newRememberAuth()
+			issued := rememberCookie(t, a, 1)
+			rememberCookie(t, a, 1)
+			rememberCookie(t, a, 2)
+
+			user, _ := a.store.FindByID(1)
+			if err := a.ChangePassword(user, tt.current, "new-secret"); err != tt.wantErr {
+				t.Fatalf("ChangePassword: got %v, want %v", err, tt.wantErr)
+			}
+			if len(tokens) != tt.wantTokens {
+				t.Fatalf("%d tokens left, want %d", len(tokens), tt.wantTokens)
+			}
+			_, err := a.Authenticate(httptest.NewRecorder(), requestWith(map[string]string{RememberCookieName: issued}))
+			if (err == nil) != (tt.wantErr != nil) {
+				t.Fatalf("remembered login after ChangePassword: %v", err)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       bool
+		remember   bool
+		wantStatus int
+		wantUser   bool
+	}{
+		{name: "guest is redirected", wantStatus: http.StatusFound},
+		{name: "guest asking for JSON", json: true, wantStatus: http.StatusUnauthorized},
+		{name: "remembered user", remember: true, wantStatus: http.StatusOK, wantUser: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newRememberAuth()
+			cookies := map[string]string{}
+			if tt.remember {
+				cookies[RememberCookieName] = rememberCookie(t, a, 1)
+			}
+			r := requestWith(cookies)
+			if tt.json {
+				r.Header.Set("Accept", "application/json")
+			}
+			w := httptest.NewRecorder()
+
+			var seen User
+			handler := a.Middleware("/login")(func(ctx *gola.Context) {
+				seen, _ = UserFrom(ctx.Request)
+				ctx.String(http.StatusOK, "dashboard")
+			})
+			handler(&gola.Context{Writer: w, Request: r})
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusFound && w.Header().Get("Location") != "/login" {
+				t.Fatalf("redirected to %q", w.Header().Get("Location"))
+			}
+			if (seen != nil) != tt.wantUser {
+				t.Fatalf("handler saw user %v", seen)
+			}
+			if tt.wantUser && responseCookie(w, "session") == "" {
+				t.Fatal("the remembered login started no session")
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// pkg/auth/throttle.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"mygola/pkg/cache"
+	"mygola/pkg/events"
+)
+
+// EventLockout is dispatched with a LockoutEvent whenever a login key gets locked
+const EventLockout = "auth.lockout"
+
+// ErrTooManyAttempts is returned while a login key is locked out
+type ErrTooManyAttempts struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrTooManyAttempts) Error() string {
+	return fmt.Sprintf("too many login attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// LockoutEvent describes a lockout, for alerting on credential stuffing
+type LockoutEvent struct {
+	Email      string
+	IP         string
+	Lockouts   int
+	RetryAfter time.Duration
+	At         time.Time
+}
+
+// ThrottleConfig controls when and for how long logins are locked
+type ThrottleConfig struct {
+	MaxAttempts int           // failed attempts allowed inside DecayWindow
+	DecayWindow time.Duration // how long failed attempts are remembered
+	LockoutBase time.Duration // length of the first lockout, doubled for each following one
+	LockoutMax  time.Duration // upper bound for a single lockout
+}
+
+func DefaultThrottleConfig() ThrottleConfig {
+	return ThrottleConfig{
+		MaxAttempts: 5,
+		DecayWindow: 15 * time.Minute,
+		LockoutBase: time.Minute,
+		LockoutMax:  24 * time.Hour,
+	}
+}
+
+// Throttler counts failed logins per email+IP in the cache and locks the
+// pair out for exponentially growing windows. The counts are kept with the
+// cache's atomic Increment, so app instances sharing a cache share the
+// limits; with a MemoryCache they only hold within one process.
+type Throttler struct {
+	cache  cache.Cache
+	config ThrottleConfig
+	events *events.Dispatcher
+}
+
+func NewThrottler(c cache.Cache, config ThrottleConfig, dispatcher *events.Dispatcher) *Throttler {
+	return &Throttler{
+		cache:  c,
+		config: config,
+		events: dispatcher,
+	}
+}
+
+// UseThrottler enables login throttling for Attempt and AttemptFrom
+func (a *Auth) UseThrottler(t *Throttler) {
+	a.throttle = t
+}
+
+// AttemptFrom is Attempt keyed on the email and the client IP of the
+// request, so one address guessing cannot lock the account for everyone.
+func (a *Auth) AttemptFrom(r *http.Request, email, password string) (User, error) {
+	return a.attempt(email, clientIP(r), password)
+}
+
+// attempt checks credentials under the throttler. The attempt is counted
+// before the credentials are checked, so concurrent requests cannot all
+// get past the limit; errors of the user store give the attempt back.
+func (a *Auth) attempt(email, ip, password string) (User, error) {
+	if a.throttle == nil {
+		return a.verify(email, password)
+	}
+
+	attempt, err := a.throttle.reserve(email, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.verify(email, password)
+	switch {
+	case err == nil:
+		a.throttle.Clear(email, ip)
+		return user, nil
+	case errors.Is(err, ErrInvalidCredentials):
+		if attempt >= a.throttle.config.MaxAttempts {
+			return nil, a.throttle.lockout(email, ip)
+		}
+		return nil, err
+	default:
+		a.throttle.release(email, ip)
+		return nil, err
+	}
+}
+
+// Check returns *ErrTooManyAttempts while the key is locked out
+func (t *Throttler) Check(email, ip string) error {
+	until, err := t.cache.Get(t.lockKey(email, ip))
+	if err != nil {
+		return nil
+	}
+
+	retryAfter := time.Until(time.Unix(toInt64(until), 0))
+	if retryAfter <= 0 {
+		return nil
+	}
+	return &ErrTooManyAttempts{RetryAfter: retryAfter}
+}
+
+// Hit records a failed attempt and locks the key once the threshold is
+// reached
+func (t *Throttler) Hit(email, ip string) error {
+	attempts, err := t.cache.Increment(t.key(email, ip), 1, t.config.DecayWindow)
+	if err != nil {
+		return err
+	}
+	switch {
+	case attempts < int64(t.config.MaxAttempts):
+		return nil
+	case attempts == int64(t.config.MaxAttempts):
+		return t.lockout(email, ip)
+	default:
+		// Another hit reached the threshold and locks the key
+		return t.tooMany(email, ip)
+	}
+}
+
+// reserve checks the lock and counts an attempt, returning its number.
+// Attempts past the last allowed one are refused right away; the last one
+// locks the key if its credentials are wrong.
+func (t *Throttler) reserve(email, ip string) (int, error) {
+	if err := t.Check(email, ip); err != nil {
+		return 0, err
+	}
+	attempts, err := t.cache.Increment(t.key(email, ip), 1, t.config.DecayWindow)
+	if err != nil {
+		return 0, err
+	}
+	// lockout sets the lock before it resets the count, so an attempt
+	// counted from zero again sees the lock here
+	if err := t.Check(email, ip); err != nil {
+		return 0, err
+	}
+	if attempts > int64(t.config.MaxAttempts) {
+		return 0, t.tooMany(email, ip)
+	}
+	return int(attempts), nil
+}
+
+// release gives back a reserved attempt that failed for another reason
+// than the credentials
+func (t *Throttler) release(email, ip string) {
+	t.cache.Increment(t.key(email, ip), -1, t.config.DecayWindow)
+}
+
+// tooMany is the error of an attempt refused while the lockout it ran
+// into is being started, or already is
+func (t *Throttler) tooMany(email, ip string) error {
+	if err := t.Check(email, ip); err != nil {
+		return err
+	}
+	return &ErrTooManyAttempts{RetryAfter: t.lockoutDuration(t.count(t.lockoutsKey(email, ip)) + 1)}
+}
+
+// lockout starts a new lockout, longer than the previous one
+func (t *Throttler) lockout(email, ip string) error {
+	lockouts, err := t.cache.Increment(t.lockoutsKey(email, ip), 1, t.config.LockoutMax+t.config.DecayWindow)
+	if err != nil {
+		return err
+	}
+	retryAfter := t.lockoutDuration(int(lockouts))
+
+	if err := t.cache.Set(t.lockKey(email, ip), time.Now().Add(retryAfter).Unix(), retryAfter); err != nil {
+		return err
+	}
+	t.cache.Delete(t.key(email, ip))
+
+	if t.events != nil {
+		t.events.Dispatch(EventLockout, LockoutEvent{
+			Email:      email,
+			IP:         ip,
+			Lockouts:   int(lockouts),
+			RetryAfter: retryAfter,
+			At:         time.Now(),
+		})
+	}
+
+	return &ErrTooManyAttempts{RetryAfter: retryAfter}
+}
+
+// Clear forgets failed attempts and lockout history after a successful login
+func (t *Throttler) Clear(email, ip string) {
+	t.cache.Delete(t.key(email, ip))
+	t.cache.Delete(t.lockoutsKey(email, ip))
+	t.cache.Delete(t.lockKey(email, ip))
+}
+
+func (t *Throttler) lockoutDuration(lockouts int) time.Duration {
+	d := float64(t.config.LockoutBase) * math.Pow(2, float64(lockouts-1))
+	if d > float64(t.config.LockoutMax) {
+		return t.config.LockoutMax
+	}
+	return time.Duration(d)
+}
+
+func (t *Throttler) count(key string) int {
+	value, err := t.cache.Get(key)
+	if err != nil {
+		return 0
+	}
+	return int(toInt64(value))
+}
+
+func (t *Throttler) key(email, ip string) string {
+	return "login_attempts:" + strings.ToLower(strings.TrimSpace(email)) + "|" + ip
+}
+
+func (t *Throttler) lockoutsKey(email, ip string) string {
+	return "login_lockouts:" + strings.ToLower(strings.TrimSpace(email)) + "|" + ip
+}
+
+func (t *Throttler) lockKey(email, ip string) string {
+	return "login_lock:" + strings.ToLower(strings.TrimSpace(email)) + "|" + ip
+}
+
+// toInt64 normalizes cached numbers; the file cache hands them back as float64
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
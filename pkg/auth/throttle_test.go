@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mygola/pkg/cache"
+	"mygola/pkg/events"
+)
+
+// countingUsers counts the credential checks that got past the throttler
+type countingUsers struct {
+	memoryUsers
+	lookups atomic.Int64
+}
+
+func (s *countingUsers) FindByEmail(email string) (User, error) {
+	s.lookups.Add(1)
+	return s.memoryUsers.FindByEmail(email)
+}
+
+func newThrottledAuth(c cache.Cache, config ThrottleConfig) (*Auth, *countingUsers, *[]LockoutEvent) {
+	users := &countingUsers{memoryUsers: memoryUsers{1: {id: 1, email: "ada@example.com", password: "secret"}}}
+	var mu sync.Mutex
+	lockouts := &[]LockoutEvent{}
+	dispatcher := events.NewDispatcher()
+	dispatcher.Listen(EventLockout, func(payload interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		*lockouts = append(*lockouts, payload.(LockoutEvent))
+	})
+
+	a := NewAuth(users)
+	a.UseThrottler(NewThrottler(c, config, dispatcher))
+	return a, users, lockouts
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var tooMany *ErrTooManyAttempts
+	if !errors.As(err, &tooMany) {
+		t.Fatalf("got %v, want *ErrTooManyAttempts", err)
+	}
+	return tooMany.RetryAfter
+}
+
+// near allows for the lock expiry being stored in whole seconds
+func near(got, want time.Duration) bool {
+	return got <= want && got > want-2*time.Second
+}
+
+func TestLockoutWindowsGrowExponentially(t *testing.T) {
+	config := ThrottleConfig{MaxAttempts: 3, DecayWindow: time.Hour, LockoutBase: time.Minute, LockoutMax: 5 * time.Minute}
+	c := cache.NewMemoryCache()
+	a, _, lockouts := newThrottledAuth(c, config)
+
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		for attempt := 1; attempt < config.MaxAttempts; attempt++ {
+			if _, err := a.Attempt("ada@example.com", "wrong"); err != ErrInvalidCredentials {
+				t.Fatalf("lockout %d, attempt %d: got %v, want ErrInvalidCredentials", i+1, attempt, err)
+			}
+		}
+		_, err := a.Attempt("ada@example.com", "wrong")
+		if got := retryAfter(t, err); got != want {
+			t.Fatalf("lockout %d lasts %s, want %s", i+1, got, want)
+		}
+
+		// Even the right password waits for the lock
+		_, err = a.Attempt("ada@example.com", "secret")
+		if got := retryAfter(t, err); !near(got, want) {
+			t.Fatalf("lockout %d: retry after %s, want about %s", i+1, got, want)
+		}
+
+		event := (*lockouts)[len(*lockouts)-1]
+		if len(*lockouts) != i+1 || event.Lockouts != i+1 || event.RetryAfter != want || event.Email != "ada@example.com" {
+			t.Fatalf("lockout %d dispatched %+v", i+1, *lockouts)
+		}
+
+		// Let the lock run out
+		c.Delete(a.throttle.lockKey("ada@example.com", ""))
+	}
+
+	// A successful login forgets the history
+	if _, err := a.Attempt("ada@example.com", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	for attempt := 1; attempt < config.MaxAttempts; attempt++ {
+		a.Attempt("ada@example.com", "wrong")
+	}
+	_, err := a.Attempt("ada@example.com", "wrong")
+	if got := retryAfter(t, err); got != time.Minute {
+		t.Fatalf("first lockout after a login lasts %s, want the base", got)
+	}
+}
+
+func TestThrottleKeysOnEmailAndIP(t *testing.T) {
+	config := ThrottleConfig{MaxAttempts: 2, DecayWindow: time.Hour, LockoutBase: time.Minute, LockoutMax: time.Hour}
+	a, _, _ := newThrottledAuth(cache.NewMemoryCache(), config)
+	throttle := a.throttle
+
+	for i := 0; i < config.MaxAttempts; i++ {
+		throttle.Hit("Ada@Example.com ", "10.0.0.1")
+	}
+	tests := []struct {
+		email, ip string
+		locked    bool
+	}{
+		{"ada@example.com", "10.0.0.1", true},
+		{"ada@example.com", "10.0.0.2", false},
+		{"grace@example.com", "10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if err := throttle.Check(tt.email, tt.ip); (err != nil) != tt.locked {
+			t.Errorf("Check(%s, %s) = %v, locked %v", tt.email, tt.ip, err, tt.locked)
+		}
+	}
+}
+
+func TestThrottleLimitsHoldAcrossInstances(t *testing.T) {
+	config := ThrottleConfig{MaxAttempts: 5, DecayWindow: time.Hour, LockoutBase: time.Minute, LockoutMax: time.Hour}
+	shared := cache.NewMemoryCache()
+	first, users, lockouts := newThrottledAuth(shared, config)
+	second := NewAuth(users)
+	second.UseThrottler(NewThrottler(shared, config, first.throttle.events))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(a *Auth) {
+			defer wg.Done()
+			_, err := a.Attempt("ada@example.com", "wrong")
+			var tooMany *ErrTooManyAttempts
+			if err != ErrInvalidCredentials && !errors.As(err, &tooMany) {
+				t.Errorf("unexpected error %v", err)
+			}
+		}([]*Auth{first, second}[i%2])
+	}
+	wg.Wait()
+
+	if n := users.lookups.Load(); n > int64(config.MaxAttempts) {
+		t.Fatalf("%d passwords checked across instances, the limit is %d", n, config.MaxAttempts)
+	}
+	if len(*lockouts) != 1 {
+		t.Fatalf("%d lockouts dispatched, want 1", len(*lockouts))
+	}
+}
+
+func TestStoreErrorsGiveTheAttemptBack(t *testing.T) {
+	config := ThrottleConfig{MaxAttempts: 2, DecayWindow: time.Hour, LockoutBase: time.Minute, LockoutMax: time.Hour}
+	a, _, lockouts := newThrottledAuth(cache.NewMemoryCache(), config)
+	a.store = failingUsers{}
+
+	for i := 0; i < 5; i++ {
+		if _, err := a.Attempt("ada@example.com", "secret"); err == nil || errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: got %v, want the store error", i+1, err)
+		}
+	}
+	if len(*lockouts) != 0 {
+		t.Fatalf("store errors locked the key: %+v", *lockouts)
+	}
+}
+
+type failingUsers struct{}
+
+func (failingUsers) FindByID(id int) (User, error)          { return nil, errors.New("db timeout") }
+func (failingUsers) FindByEmail(email string) (User, error) { return nil, errors.New("db timeout") }
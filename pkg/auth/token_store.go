@@ -0,0 +1,71 @@
+// pkg/auth/token_store.go
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"mygola/pkg/database"
+)
+
+// DatabaseTokenStore keeps remember tokens in the remember_tokens table
+type DatabaseTokenStore struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewDatabaseTokenStore creates a store on db, whose placeholders follow the
+// connection's dialect:
+//
+//	conn, _ := database.Connection("pgsql")
+//	sqlDB, _ := conn.DB()
+//	store := auth.NewDatabaseTokenStore(sqlDB, orm.DialectFor(conn.Dialector.Name()))
+func NewDatabaseTokenStore(db *sql.DB, dialect database.Dialect) *DatabaseTokenStore {
+	return &DatabaseTokenStore{db: db, dialect: dialect}
+}
+
+func (s *DatabaseTokenStore) p(n int) string {
+	return s.dialect.Placeholder(n)
+}
+
+func (s *DatabaseTokenStore) Create(token RememberToken) error {
+	_, err := s.db.Exec(
+		fmt.Sprintf("INSERT INTO remember_tokens (selector, validator_hash, user_id, expires_at) VALUES (%s, %s, %s, %s)",
+			s.p(1), s.p(2), s.p(3), s.p(4)),
+		token.Selector, token.ValidatorHash, token.UserID, token.ExpiresAt,
+	)
+	return err
+}
+
+func (s *DatabaseTokenStore) FindBySelector(selector string) (*RememberToken, error) {
+	var token RememberToken
+	err := s.db.QueryRow(
+		"SELECT selector, validator_hash, user_id, expires_at FROM remember_tokens WHERE selector = "+s.p(1),
+		selector,
+	).Scan(&token.Selector, &token.ValidatorHash, &token.UserID, &token.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *DatabaseTokenStore) Delete(selector string) error {
+	_, err := s.db.Exec("DELETE FROM remember_tokens WHERE selector = "+s.p(1), selector)
+	return err
+}
+
+func (s *DatabaseTokenStore) DeleteForUser(userID int) error {
+	_, err := s.db.Exec("DELETE FROM remember_tokens WHERE user_id = "+s.p(1), userID)
+	return err
+}
+
+// Prune removes expired tokens; suitable for a daily scheduled task
+func (s *DatabaseTokenStore) Prune() error {
+	_, err := s.db.Exec("DELETE FROM remember_tokens WHERE expires_at < "+s.p(1), time.Now())
+	return err
+}
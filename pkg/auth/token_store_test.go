@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"mygola/pkg/database"
+	"mygola/pkg/database/schema"
+)
+
+func TestDatabaseTokenStoreOnSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dialect := database.SQLiteDialect{}
+	err = schema.NewBuilder(db, dialect).Create("remember_tokens", func(t *schema.Blueprint) {
+		t.Increments("id")
+		t.String("selector", 32).Unique()
+		t.Char("validator_hash", 64)
+		t.Integer("user_id").Index()
+		t.DateTime("expires_at")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewDatabaseTokenStore(db, dialect)
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	for _, token := range []RememberToken{
+		{Selector: "a", ValidatorHash: "hash-a", UserID: 1, ExpiresAt: expires},
+		{Selector: "b", ValidatorHash: "hash-b", UserID: 1, ExpiresAt: expires},
+		{Selector: "c", ValidatorHash: "hash-c", UserID: 2, ExpiresAt: time.Now().Add(-time.Hour)},
+	} {
+		if err := store.Create(token); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	token, err := store.FindBySelector("a")
+	if err != nil {
+		t.Fatalf("FindBySelector: %v", err)
+	}
+	if token.ValidatorHash != "hash-a" || token.UserID != 1 || !token.ExpiresAt.Equal(expires) {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+
+	if err := store.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.FindBySelector("a"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("deleted token: got %v, want ErrTokenNotFound", err)
+	}
+	if err := store.DeleteForUser(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.FindBySelector("b"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("token of a revoked user: got %v, want ErrTokenNotFound", err)
+	}
+	if err := store.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.FindBySelector("c"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expired token after Prune: got %v, want ErrTokenNotFound", err)
+	}
+}
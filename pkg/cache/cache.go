@@ -20,8 +20,18 @@ type Cache interface {
 	Set(key string, value interface{}, expiration time.Duration) error
 	Delete(key string) error
 	Has(key string) bool
+
+	// Increment adds delta to the counter at key, which starts at 0 when
+	// missing or expired, and returns the new value in one atomic step,
+	// also for processes sharing the cache. A new counter expires after
+	// expiration; an existing one keeps its expiry.
+	Increment(key string, delta int64, expiration time.Duration) (int64, error)
 }
 
+// lockTimeout bounds the wait for the lock file of a FileCache counter;
+// older lock files are left behind by crashed processes
+const lockTimeout = 10 * time.Second
+
 // ======================
 // MemoryCache
 // ======================
@@ -92,6 +102,23 @@ func (c *MemoryCache) Has(key string) bool {
 	return time.Now().Before(item.expiration)
 }
 
+func (c *MemoryCache) Increment(key string, delta int64, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.items[key]
+	if !exists || time.Now().After(item.expiration) {
+		item = memoryItem{value: int64(0), expiration: time.Now().Add(expiration)}
+	}
+	value, err := toInt64(item.value)
+	if err != nil {
+		return 0, err
+	}
+	item.value = value + delta
+	c.items[key] = item
+	return value + delta, nil
+}
+
 func (c *MemoryCache) cleanup() {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
@@ -134,6 +161,11 @@ func (c *RedisCache) Has(key string) bool {
 	return false
 }
 
+// Increment maps to INCRBY followed by EXPIRE ... NX
+func (c *RedisCache) Increment(key string, delta int64, expiration time.Duration) (int64, error) {
+	return 0, errors.New("redis cache not implemented")
+}
+
 // ======================
 // FileCache (minimal working version)
 // ======================
@@ -240,3 +272,79 @@ func (c *FileCache) Has(key string) bool {
 	}
 	return true
 }
+
+// Increment locks the counter with a lock file next to it, so processes
+// sharing the cache directory count together
+func (c *FileCache) Increment(key string, delta int64, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file := c.filename(key)
+	unlock, err := lockFile(file + ".lock")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var item struct {
+		Value      interface{} `json:"value"`
+		Expiration int64       `json:"expiration"`
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	if err != nil || json.Unmarshal(data, &item) != nil || time.Now().Unix() > item.Expiration {
+		item.Value, item.Expiration = int64(0), time.Now().Add(expiration).Unix()
+	}
+
+	value, err := toInt64(item.Value)
+	if err != nil {
+		return 0, err
+	}
+	item.Value = value + delta
+	if data, err = json.Marshal(item); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return 0, err
+	}
+	return value + delta, nil
+}
+
+// lockFile creates path exclusively, waiting while another process holds
+// it, and returns the function removing it
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockTimeout {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("cache: %s held for more than %s", path, lockTimeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// toInt64 reads a counter; the file cache hands numbers back as float64
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("cache: %T value is not a counter", value)
+}
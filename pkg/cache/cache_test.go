@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncrement(t *testing.T) {
+	file, err := NewFileCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	caches := map[string]Cache{
+		"memory": NewMemoryCache(),
+		"file":   file,
+	}
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			steps := []struct {
+				key   string
+				delta int64
+				want  int64
+			}{
+				{"hits", 1, 1},
+				{"hits", 1, 2},
+				{"hits", 5, 7},
+				{"hits", -1, 6},
+				{"other", 1, 1},
+			}
+			for _, step := range steps {
+				got, err := c.Increment(step.key, step.delta, time.Minute)
+				if err != nil || got != step.want {
+					t.Fatalf("Increment(%s, %d) = %d, %v; want %d", step.key, step.delta, got, err, step.want)
+				}
+			}
+
+			if _, err := c.Increment("expired", 3, -time.Second); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := c.Increment("expired", 1, time.Minute); got != 1 {
+				t.Fatalf("expired counter continued at %d, want a new one", got)
+			}
+
+			c.Set("name", "ada", time.Minute)
+			if _, err := c.Increment("name", 1, time.Minute); err == nil {
+				t.Fatal("incremented a string value")
+			}
+		})
+	}
+}
+
+func TestFileCacheIncrementIsSharedBetweenInstances(t *testing.T) {
+	dir := t.TempDir()
+	var caches []*FileCache
+	for i := 0; i < 2; i++ {
+		c, err := NewFileCache(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		caches = append(caches, c)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(c *FileCache) {
+			defer wg.Done()
+			if _, err := c.Increment("hits", 1, time.Minute); err != nil {
+				t.Error(err)
+			}
+		}(caches[i%2])
+	}
+	wg.Wait()
+
+	if got, _ := caches[0].Increment("hits", 0, time.Minute); got != 50 {
+		t.Fatalf("counted %d hits, want 50", got)
+	}
+}
@@ -0,0 +1,112 @@
+// pkg/database/dialect.go
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect holds the SQL differences between the supported drivers
+type Dialect interface {
+	// Name is the driver name as used in the config: mysql, postgres,
+	// sqlite or sqlserver
+	Name() string
+	// Placeholder returns the bind marker of the n-th argument, from 1
+	Placeholder(n int) string
+	// Quote quotes a single identifier
+	Quote(ident string) string
+	// LimitOffset returns the clause appended after ORDER BY, or ""
+	LimitOffset(limit, offset int) string
+}
+
+// DialectFor returns the dialect of a driver name, defaulting to MySQL
+func DialectFor(driver string) Dialect {
+	switch strings.ToLower(driver) {
+	case "postgres", "postgresql", "pgx", "pgsql":
+		return PostgresDialect{}
+	case "sqlite", "sqlite3":
+		return SQLiteDialect{}
+	case "sqlserver", "mssql":
+		return SQLServerDialect{}
+	}
+	return MySQLDialect{}
+}
+
+type MySQLDialect struct{}
+
+func (MySQLDialect) Name() string             { return "mysql" }
+func (MySQLDialect) Placeholder(n int) string { return "?" }
+func (MySQLDialect) Quote(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
+func (MySQLDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset, "18446744073709551615")
+}
+
+type PostgresDialect struct{}
+
+func (PostgresDialect) Name() string             { return "postgres" }
+func (PostgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+func (PostgresDialect) Quote(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+func (PostgresDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset, "ALL")
+}
+
+type SQLiteDialect struct{}
+
+func (SQLiteDialect) Name() string             { return "sqlite" }
+func (SQLiteDialect) Placeholder(n int) string { return "?" }
+func (SQLiteDialect) Quote(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+func (SQLiteDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset, "-1")
+}
+
+type SQLServerDialect struct{}
+
+func (SQLServerDialect) Name() string             { return "sqlserver" }
+func (SQLServerDialect) Placeholder(n int) string { return fmt.Sprintf("@p%d", n) }
+func (SQLServerDialect) Quote(ident string) string {
+	return "[" + strings.ReplaceAll(ident, "]", "]]") + "]"
+}
+
+// LimitOffset uses OFFSET ... FETCH, which SQL Server only accepts after an
+// ORDER BY; the query builder adds ORDER BY (SELECT NULL) when needed
+func (SQLServerDialect) LimitOffset(limit, offset int) string {
+	if limit < 0 && offset <= 0 {
+		return ""
+	}
+	clause := fmt.Sprintf("OFFSET %d ROWS", max(offset, 0))
+	if limit >= 0 {
+		clause += fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", limit)
+	}
+	return clause
+}
+
+// limitOffset builds LIMIT/OFFSET; an offset without a limit needs the
+// dialect's "no limit" value
+func limitOffset(limit, offset int, noLimit string) string {
+	switch {
+	case limit >= 0 && offset > 0:
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	case limit >= 0:
+		return fmt.Sprintf("LIMIT %d", limit)
+	case offset > 0:
+		return fmt.Sprintf("LIMIT %s OFFSET %d", noLimit, offset)
+	}
+	return ""
+}
+
+// quoteColumn quotes a possibly qualified column, "posts.id" or "posts.*"
+func quoteColumn(d Dialect, column string) string {
+	parts := strings.Split(column, ".")
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = d.Quote(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var dialects = []Dialect{MySQLDialect{}, PostgresDialect{}, SQLiteDialect{}, SQLServerDialect{}}
+
+// goldenSQL collects titled statements for a golden file
+type goldenSQL struct {
+	strings.Builder
+}
+
+func (g *goldenSQL) add(title, query string, args ...interface{}) {
+	fmt.Fprintf(g, "-- %s\n%s;\n", title, strings.TrimSuffix(query, ";"))
+	if len(args) > 0 {
+		fmt.Fprintf(g, "-- args: %v\n", args)
+	}
+	g.WriteString("\n")
+}
+
+// checkGolden compares got with testdata/<name>.golden; go test -update
+// rewrites the file
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("SQL differs from %s (run go test -update after checking it):\n%s", path, got)
+	}
+}
+
+func TestDialectSQL(t *testing.T) {
+	for _, d := range dialects {
+		t.Run(d.Name(), func(t *testing.T) {
+			orm := NewORM(nil).UseDialect(d)
+			var sql goldenSQL
+
+			sql.add("migrations table", MigrationsTableSQL(d))
+
+			query, args, err := orm.Table("posts").Select("id", "posts.title").
+				Where("user_id", "=", 7).
+				WhereIn("status", []string{"draft", "published"}).
+				WhereNull("deleted_at").
+				OrderBy("created_at", "desc").
+				Limit(10).Offset(20).
+				ToSQL()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql.add("select with where, order and page", query, args...)
+
+			query, args, err = orm.Table("posts").Offset(5).ToSQL()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql.add("offset without limit or order", query, args...)
+
+			columns := []string{"sku", "name", "price"}
+			values := []interface{}{"A-1", "Pen", 1.5}
+			query, args = orm.upsertSQL("products", columns, values, []string{"sku"}, []string{"name", "price"})
+			sql.add("upsert", query, args...)
+			query, args = orm.upsertSQL("products", columns, values, []string{"sku"}, nil)
+			sql.add("upsert without update columns", query, args...)
+
+			checkGolden(t, d.Name(), sql.String())
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// pkg/database/hooks.go
+package database
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Model hooks. The ORM calls them when a model implements them; an error
+// from a Before hook stops the write.
+//
+//	func (p *Product) BeforeCreate() error {
+//		if p.ID == "" {
+//			p.ID = helpers.GenerateID()
+//		}
+//		return nil
+//	}
+//
+// Create and Update run the save hooks around their own: BeforeSave,
+// BeforeCreate, INSERT, AfterCreate, AfterSave.
+type (
+	BeforeCreateHook interface{ BeforeCreate() error }
+	AfterCreateHook  interface{ AfterCreate() error }
+	BeforeUpdateHook interface{ BeforeUpdate() error }
+	AfterUpdateHook  interface{ AfterUpdate() error }
+	BeforeSaveHook   interface{ BeforeSave() error }
+	AfterSaveHook    interface{ AfterSave() error }
+	BeforeDeleteHook interface{ BeforeDelete() error }
+	AfterDeleteHook  interface{ AfterDelete() error }
+)
+
+// Observer methods, for reactions that do not belong in the model such as
+// cache busting or audit logs. An observer implements any of them and is
+// registered per model type with Observers.Observe.
+type (
+	CreatingObserver interface{ Creating(model Model) error }
+	CreatedObserver  interface{ Created(model Model) error }
+	UpdatingObserver interface{ Updating(model Model) error }
+	UpdatedObserver  interface{ Updated(model Model) error }
+	SavingObserver   interface{ Saving(model Model) error }
+	SavedObserver    interface{ Saved(model Model) error }
+	DeletingObserver interface{ Deleting(model Model) error }
+	DeletedObserver  interface{ Deleted(model Model) error }
+)
+
+// Model events, in the order the hooks and observers are called
+const (
+	eventSaving   = "saving"
+	eventCreating = "creating"
+	eventCreated  = "created"
+	eventUpdating = "updating"
+	eventUpdated  = "updated"
+	eventSaved    = "saved"
+	eventDeleting = "deleting"
+	eventDeleted  = "deleted"
+)
+
+// Observers maps model types to their observers
+type Observers struct {
+	mu        sync.RWMutex
+	observers map[reflect.Type][]interface{}
+}
+
+func NewObservers() *Observers {
+	return &Observers{observers: make(map[reflect.Type][]interface{})}
+}
+
+// DefaultObservers is used by every ORM unless UseObservers replaces it
+var DefaultObservers = NewObservers()
+
+// Observe registers an observer for a model type:
+//
+//	observers.Observe(&models.Product{}, &ProductObserver{cache: appCache})
+func (r *Observers) Observe(model Model, observer interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := indirect(reflect.TypeOf(model))
+	r.observers[t] = append(r.observers[t], observer)
+}
+
+func (r *Observers) forType(t reflect.Type) []interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]interface{}(nil), r.observers[t]...)
+}
+
+// UseObservers replaces the observer registry of the ORM
+func (o *ORM) UseObservers(observers *Observers) *ORM {
+	o.observers = observers
+	return o
+}
+
+// fire calls the model's hook for an event, then its observers, stopping at
+// the first error
+func (o *ORM) fire(event string, model Model) error {
+	if err := callHook(event, model); err != nil {
+		return err
+	}
+	if o.observers == nil {
+		return nil
+	}
+	for _, observer := range o.observers.forType(indirect(reflect.TypeOf(model))) {
+		if err := callObserver(event, observer, model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func callHook(event string, model Model) error {
+	switch event {
+	case eventSaving:
+		if h, ok := model.(BeforeSaveHook); ok {
+			return h.BeforeSave()
+		}
+	case eventCreating:
+		if h, ok := model.(BeforeCreateHook); ok {
+			return h.BeforeCreate()
+		}
+	case eventCreated:
+		if h, ok := model.(AfterCreateHook); ok {
+			return h.AfterCreate()
+		}
+	case eventUpdating:
+		if h, ok := model.(BeforeUpdateHook); ok {
+			return h.BeforeUpdate()
+		}
+	case eventUpdated:
+		if h, ok := model.(AfterUpdateHook); ok {
+			return h.AfterUpdate()
+		}
+	case eventSaved:
+		if h, ok := model.(AfterSaveHook); ok {
+			return h.AfterSave()
+		}
+	case eventDeleting:
+		if h, ok := model.(BeforeDeleteHook); ok {
+			return h.BeforeDelete()
+		}
+	case eventDeleted:
+		if h, ok := model.(AfterDeleteHook); ok {
+			return h.AfterDelete()
+		}
+	}
+	return nil
+}
+
+func callObserver(event string, observer interface{}, model Model) error {
+	switch event {
+	case eventSaving:
+		if ob, ok := observer.(SavingObserver); ok {
+			return ob.Saving(model)
+		}
+	case eventCreating:
+		if ob, ok := observer.(CreatingObserver); ok {
+			return ob.Creating(model)
+		}
+	case eventCreated:
+		if ob, ok := observer.(CreatedObserver); ok {
+			return ob.Created(model)
+		}
+	case eventUpdating:
+		if ob, ok := observer.(UpdatingObserver); ok {
+			return ob.Updating(model)
+		}
+	case eventUpdated:
+		if ob, ok := observer.(UpdatedObserver); ok {
+			return ob.Updated(model)
+		}
+	case eventSaved:
+		if ob, ok := observer.(SavedObserver); ok {
+			return ob.Saved(model)
+		}
+	case eventDeleting:
+		if ob, ok := observer.(DeletingObserver); ok {
+			return ob.Deleting(model)
+		}
+	case eventDeleted:
+		if ob, ok := observer.(DeletedObserver); ok {
+			return ob.Deleted(model)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// pkg/database/migration_lock.go
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MigrationLockName is the database lock migrators hold while they run
+const MigrationLockName = "mygola_migrations"
+
+// MigrationLockTimeout is how long a migrator waits for another one to finish
+var MigrationLockTimeout = 60 * time.Second
+
+// lock takes the migration lock on a dedicated connection, since MySQL and
+// Postgres locks belong to the session that took them. SQLite serialises
+// writers itself and takes no lock. In pretend mode nothing is locked.
+func (m *Migrator) lock() (func(), error) {
+	noop := func() {}
+	if m.pretend || m.dialect.Name() == "sqlite" {
+		return noop, nil
+	}
+
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return noop, fmt.Errorf("failed to get connection for migration lock: %v", err)
+	}
+
+	timeout := int(MigrationLockTimeout / time.Second)
+	var acquired bool
+	var release string
+	switch m.dialect.Name() {
+	case "mysql":
+		var got sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", MigrationLockName, timeout).Scan(&got)
+		acquired = got.Valid && got.Int64 == 1
+		release = "SELECT RELEASE_LOCK(?)"
+	case "postgres":
+		acquired, err = pollPostgresLock(ctx, conn)
+		release = "SELECT pg_advisory_unlock(hashtext($1))"
+	case "sqlserver":
+		var status int
+		err = conn.QueryRowContext(ctx, `DECLARE @status INT;
+EXEC @status = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @p2;
+SELECT @status`, MigrationLockName, timeout*1000).Scan(&status)
+		acquired = status >= 0
+		release = "EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'"
+	default:
+		conn.Close()
+		return noop, nil
+	}
+
+	if err != nil {
+		conn.Close()
+		return noop, fmt.Errorf("failed to take migration lock: %v", err)
+	}
+	if !acquired {
+		conn.Close()
+		return noop, fmt.Errorf("another migration is running: lock %q not released within %s",
+			MigrationLockName, MigrationLockTimeout)
+	}
+
+	return func() {
+		conn.ExecContext(ctx, release, MigrationLockName)
+		conn.Close()
+	}, nil
+}
+
+// pollPostgresLock retries pg_try_advisory_lock until MigrationLockTimeout,
+// as pg_advisory_lock would wait forever
+func pollPostgresLock(ctx context.Context, conn *sql.Conn) (bool, error) {
+	deadline := time.Now().Add(MigrationLockTimeout)
+	for {
+		var acquired bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", MigrationLockName).Scan(&acquired)
+		if err != nil || acquired {
+			return acquired, err
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -0,0 +1,289 @@
+// pkg/database/migration_source.go
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GoMigration is a migration written in Go. Register it from an init
+// function in database/migrations, named like the SQL files so it sorts
+// among them:
+//
+//	func init() {
+//		database.RegisterMigration(database.GoMigration{
+//			Name: "20261020120000_create_tags_table",
+//			Up: func(exec database.Executor, dialect database.Dialect) error {
+//				return schema.NewBuilder(exec, dialect).Create("tags", func(t *schema.Blueprint) {
+//					t.ID()
+//					t.String("name")
+//				})
+//			},
+//			Down: func(exec database.Executor, dialect database.Dialect) error {
+//				return schema.NewBuilder(exec, dialect).DropIfExists("tags")
+//			},
+//		})
+//	}
+//
+// Up and Down run in the migration's transaction where the dialect allows it.
+type GoMigration struct {
+	Name string
+	Up   func(exec Executor, dialect Dialect) error
+	Down func(exec Executor, dialect Dialect) error
+}
+
+var (
+	goMigrations   = make(map[string]GoMigration)
+	goMigrationsMu sync.RWMutex
+)
+
+// RegisterMigration adds a Go migration; registering a name twice panics
+func RegisterMigration(migration GoMigration) {
+	goMigrationsMu.Lock()
+	defer goMigrationsMu.Unlock()
+
+	if migration.Name == "" || migration.Up == nil {
+		panic("database: a Go migration needs a Name and an Up function")
+	}
+	if _, ok := goMigrations[migration.Name]; ok {
+		panic(fmt.Sprintf("database: migration %q registered twice", migration.Name))
+	}
+	goMigrations[migration.Name] = migration
+}
+
+// migrationSource is one migration, from an up/down SQL pair or from Go
+type migrationSource struct {
+	name     string
+	upFile   string // SQL migrations
+	downFile string
+	goMig    *GoMigration
+}
+
+// migrationSources lists the SQL and Go migrations ordered by name, i.e. by
+// their timestamp prefix
+func (m *Migrator) migrationSources(migrationsPath string) ([]migrationSource, error) {
+	// A project with only Go migrations needs no directory
+	files, err := m.GetMigrationFiles(migrationsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to get migration files: %v", err)
+	}
+
+	byName := make(map[string]migrationSource)
+	for _, file := range files {
+		name := MigrationName(file)
+		source := migrationSource{name: name, upFile: filepath.Join(migrationsPath, file)}
+		downFile := filepath.Join(migrationsPath, name+"_down.sql")
+		if _, err := os.Stat(downFile); err == nil {
+			source.downFile = downFile
+		}
+		byName[name] = source
+	}
+
+	goMigrationsMu.RLock()
+	for name, migration := range goMigrations {
+		if _, ok := byName[name]; ok {
+			goMigrationsMu.RUnlock()
+			return nil, fmt.Errorf("migration %s exists both as SQL file and in Go", name)
+		}
+		migration := migration
+		byName[name] = migrationSource{name: name, goMig: &migration}
+	}
+	goMigrationsMu.RUnlock()
+
+	sources := make([]migrationSource, 0, len(byName))
+	for _, source := range byName {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].name < sources[j].name
+	})
+	return sources, nil
+}
+
+// up runs a migration and records it in the given batch
+func (m *Migrator) up(source migrationSource, batch int) error {
+	if source.goMig == nil {
+		return m.RunMigrationFile(source.upFile, batch)
+	}
+
+	record := fmt.Sprintf("INSERT INTO migrations (name, batch) VALUES (%s, %s)",
+		m.dialect.Placeholder(1), m.dialect.Placeholder(2))
+	return m.executeGo(source.goMig.Up, func(exec Executor) error {
+		_, err := exec.Exec(record, source.name, batch)
+		return err
+	})
+}
+
+// down reverts a migration and removes its record
+func (m *Migrator) down(source migrationSource) error {
+	remove := func(exec Executor) error {
+		_, err := exec.Exec(fmt.Sprintf("DELETE FROM migrations WHERE name = %s", m.dialect.Placeholder(1)), source.name)
+		if err != nil {
+			return fmt.Errorf("failed to delete migration record: %v", err)
+		}
+		return nil
+	}
+
+	if source.goMig != nil {
+		if source.goMig.Down == nil {
+			return fmt.Errorf("Go migration %s has no Down function", source.name)
+		}
+		return m.executeGo(source.goMig.Down, remove)
+	}
+
+	if source.downFile == "" {
+		return fmt.Errorf("down migration file not found: %s_down.sql", source.name)
+	}
+	content, err := os.ReadFile(source.downFile)
+	if err != nil {
+		return err
+	}
+	return m.execute(string(content), remove)
+}
+
+// executeGo runs a Go migration step followed by the bookkeeping, in one
+// transaction when the dialect allows it. When pretending, the statements
+// it executes are printed instead; its reads still reach the database.
+func (m *Migrator) executeGo(step func(exec Executor, dialect Dialect) error, bookkeeping func(exec Executor) error) error {
+	if m.pretend {
+		return step(pretendExecutor{m.db}, m.dialect)
+	}
+
+	run := func(exec Executor) error {
+		if err := step(exec, m.dialect); err != nil {
+			return err
+		}
+		return bookkeeping(exec)
+	}
+	if m.dialect.Name() == "mysql" {
+		return run(m.db)
+	}
+	return m.Transaction(func(tx *sql.Tx) error {
+		return run(tx)
+	})
+}
+
+// pretendExecutor prints the statements a Go migration executes
+type pretendExecutor struct {
+	Executor
+}
+
+func (p pretendExecutor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if len(args) > 0 {
+		fmt.Printf("  %s; -- %v\n", query, args)
+	} else {
+		fmt.Printf("  %s;\n", query)
+	}
+	return pretendResult(0), nil
+}
+
+type pretendResult int64
+
+func (r pretendResult) LastInsertId() (int64, error) { return 0, nil }
+func (r pretendResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+// MigrationStatus is a line of migrate:status
+type MigrationStatus struct {
+	Name     string
+	Go       bool // registered in Go rather than an SQL file
+	Ran      bool
+	Batch    int
+	Modified bool // the SQL file changed after it ran
+	Missing  bool // recorded as run but no longer known
+	Dumped   bool // pruned, the schema dump covers it
+}
+
+// Status lists every known or recorded migration in order. It waits for a
+// running migrator, which may be upgrading the migrations table.
+func (m *Migrator) Status(migrationsPath string) ([]MigrationStatus, error) {
+	if err := m.locked(func() error { return m.prepare(migrationsPath) }); err != nil {
+		return nil, err
+	}
+	run, err := m.GetRunMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run migrations: %v", err)
+	}
+	sources, err := m.migrationSources(migrationsPath)
+	if err != nil {
+		return nil, err
+	}
+	drifted, err := m.DriftedMigrations(migrationsPath, run)
+	if err != nil {
+		return nil, err
+	}
+
+	ran := make(map[string]Migration, len(run))
+	for _, migration := range run {
+		ran[migration.Name] = migration
+	}
+	modified := make(map[string]bool, len(drifted))
+	for _, name := range drifted {
+		modified[name] = true
+	}
+
+	statuses := make([]MigrationStatus, 0, len(sources))
+	known := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		known[source.name] = true
+		migration, ok := ran[source.name]
+		statuses = append(statuses, MigrationStatus{
+			Name:     source.name,
+			Go:       source.goMig != nil,
+			Ran:      ok,
+			Batch:    migration.Batch,
+			Modified: modified[source.name],
+		})
+	}
+	var dump []byte
+	if m.schemaDump != "" {
+		dump, _ = os.ReadFile(m.schemaDump)
+	}
+	for _, migration := range run {
+		if !known[migration.Name] {
+			dumped := strings.Contains(string(dump), quoteString(migration.Name))
+			statuses = append(statuses, MigrationStatus{
+				Name:    migration.Name,
+				Ran:     true,
+				Batch:   migration.Batch,
+				Missing: !dumped,
+				Dumped:  dumped,
+			})
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses, nil
+}
+
+// String formats a status line, e.g. "Ran     20250101_create_posts (batch 1, modified)"
+func (s MigrationStatus) String() string {
+	state := "Pending"
+	var notes []string
+	if s.Ran {
+		state = "Ran"
+		notes = append(notes, fmt.Sprintf("batch %d", s.Batch))
+	}
+	if s.Go {
+		notes = append(notes, "go")
+	}
+	if s.Modified {
+		notes = append(notes, "modified")
+	}
+	if s.Missing {
+		notes = append(notes, "missing")
+	}
+	if s.Dumped {
+		notes = append(notes, "in schema dump")
+	}
+	line := fmt.Sprintf("%-8s%s", state, s.Name)
+	if len(notes) > 0 {
+		line += " (" + strings.Join(notes, ", ") + ")"
+	}
+	return line
+}
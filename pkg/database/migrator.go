@@ -2,7 +2,10 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,39 +14,179 @@ import (
 	"time"
 )
 
+// NoTransactionMarker in a migration file runs it outside a transaction,
+// e.g. for CREATE INDEX CONCURRENTLY on Postgres
+const NoTransactionMarker = "-- migrate:no-transaction"
+
 // Migration represents a database migration
 type Migration struct {
 	ID        int
 	Name      string
 	Batch     int
+	Checksum  string // SHA-256 of the up file, "" for rows recorded before checksums
 	CreatedAt time.Time
 }
 
-// Migrator handles database migrations
+// Migrator handles database migrations. Each file runs in its own
+// transaction where the database has transactional DDL (all but MySQL),
+// and concurrent migrators wait for each other on a database lock.
 type Migrator struct {
-	db *sql.DB
+	db         *sql.DB
+	dialect    Dialect
+	pretend    bool
+	schemaDump string
 }
 
-// NewMigrator creates a new Migrator instance
+// NewMigrator creates a new Migrator instance for a MySQL connection, see
+// UseDialect for others
 func NewMigrator(db *sql.DB) *Migrator {
-	return &Migrator{db: db}
+	return &Migrator{db: db, dialect: MySQLDialect{}}
+}
+
+// UseDialect switches the SQL dialect of the bookkeeping queries
+func (m *Migrator) UseDialect(dialect Dialect) *Migrator {
+	m.dialect = dialect
+	return m
+}
+
+// Pretend makes RunMigrations and RollbackMigrations print the SQL they
+// would run instead of running it
+func (m *Migrator) Pretend(pretend bool) *Migrator {
+	m.pretend = pretend
+	return m
+}
+
+// Transaction runs fn in a transaction with the same commit, rollback and
+// retry rules as ORM.Transaction
+func (m *Migrator) Transaction(fn func(tx *sql.Tx) error) error {
+	return transaction(m.db, fn)
 }
 
 // CreateMigrationsTable creates the migrations table if it doesn't exist
 func (m *Migrator) CreateMigrationsTable() error {
-	query := `CREATE TABLE IF NOT EXISTS migrations (
+	_, err := m.db.Exec(MigrationsTableSQL(m.dialect))
+	return err
+}
+
+// prepare creates the migrations table and upgrades one written by an
+// older version. The caller holds the lock, so concurrent migrators do
+// not both alter and rewrite the table.
+func (m *Migrator) prepare(migrationsPath string) error {
+	if err := m.CreateMigrationsTable(); err != nil {
+		return fmt.Errorf("failed to create migrations table: %v", err)
+	}
+
+	// Tables created before checksums were recorded get the column added
+	if _, err := m.db.Exec("SELECT checksum FROM migrations WHERE 1 = 0"); err != nil {
+		if _, err := m.db.Exec("ALTER TABLE migrations ADD checksum VARCHAR(64) NULL"); err != nil {
+			return fmt.Errorf("failed to add checksum column: %v", err)
+		}
+	}
+	if err := m.normalizeMigrationNames(migrationsPath); err != nil {
+		return fmt.Errorf("failed to normalize migration names: %v", err)
+	}
+	return nil
+}
+
+// normalizeMigrationNames rewrites rows recorded by file name before
+// MigrationName: "20250823222812_flexiload_up" becomes
+// "20250823222812_flexiload", and "_down" rows, left by down files that
+// were run as migrations, are deleted. Only rows named after a file in
+// migrationsPath are touched, so a Go migration or a pruned file whose name
+// happens to end in "_up" or "_down" keeps its record.
+func (m *Migrator) normalizeMigrationNames(migrationsPath string) error {
+	rows, err := m.db.Query("SELECT id, name FROM migrations")
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool)
+	legacy := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		names[name] = true
+		if strings.HasSuffix(name, "_up") || strings.HasSuffix(name, "_down") {
+			legacy[id] = name
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	goMigrationsMu.RLock()
+	defer goMigrationsMu.RUnlock()
+
+	p1, p2 := m.dialect.Placeholder(1), m.dialect.Placeholder(2)
+	for id, name := range legacy {
+		if _, ok := goMigrations[name]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(migrationsPath, name+".sql")); err != nil {
+			continue
+		}
+
+		stripped := MigrationName(name)
+		// A row under the new name already records the migration
+		if strings.HasSuffix(name, "_down") || names[stripped] {
+			if _, err := m.db.Exec("DELETE FROM migrations WHERE id = "+p1, id); err != nil {
+				return fmt.Errorf("failed to delete migration record %s: %v", name, err)
+			}
+			continue
+		}
+		if _, err := m.db.Exec("UPDATE migrations SET name = "+p1+" WHERE id = "+p2, stripped, id); err != nil {
+			return fmt.Errorf("failed to rename migration record %s: %v", name, err)
+		}
+		names[stripped] = true
+	}
+	return nil
+}
+
+// MigrationsTableSQL returns the DDL of the migrations table for a dialect
+func MigrationsTableSQL(d Dialect) string {
+	switch d.Name() {
+	case "postgres":
+		return `CREATE TABLE IF NOT EXISTS migrations (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL UNIQUE,
+		batch INT NOT NULL,
+		checksum VARCHAR(64) NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+	case "sqlite":
+		return `CREATE TABLE IF NOT EXISTS migrations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL UNIQUE,
+		batch INTEGER NOT NULL,
+		checksum VARCHAR(64) NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+	case "sqlserver":
+		return `IF OBJECT_ID(N'migrations', N'U') IS NULL
+	CREATE TABLE migrations (
+		id INT IDENTITY(1,1) PRIMARY KEY,
+		name NVARCHAR(255) NOT NULL UNIQUE,
+		batch INT NOT NULL,
+		checksum VARCHAR(64) NULL,
+		created_at DATETIME2 DEFAULT CURRENT_TIMESTAMP
+	)`
+	}
+	return `CREATE TABLE IF NOT EXISTS migrations (
 		id INT AUTO_INCREMENT PRIMARY KEY,
 		name VARCHAR(255) NOT NULL UNIQUE,
 		batch INT NOT NULL,
+		checksum VARCHAR(64) NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	)`
-	_, err := m.db.Exec(query)
-	return err
 }
 
 // GetRunMigrations retrieves all migrations that have been run
 func (m *Migrator) GetRunMigrations() ([]Migration, error) {
-	rows, err := m.db.Query("SELECT id, name, batch, created_at FROM migrations ORDER BY id")
+	rows, err := m.db.Query("SELECT id, name, batch, checksum, created_at FROM migrations ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
@@ -52,10 +195,12 @@ func (m *Migrator) GetRunMigrations() ([]Migration, error) {
 	var migrations []Migration
 	for rows.Next() {
 		var migration Migration
-		err := rows.Scan(&migration.ID, &migration.Name, &migration.Batch, &migration.CreatedAt)
+		var checksum sql.NullString
+		err := rows.Scan(&migration.ID, &migration.Name, &migration.Batch, &checksum, &migration.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
+		migration.Checksum = checksum.String
 		migrations = append(migrations, migration)
 	}
 
@@ -77,27 +222,69 @@ func (m *Migrator) GetNextBatchNumber() (int, error) {
 	return int(maxBatch.Int64) + 1, nil
 }
 
-// RunMigrations runs all pending migrations
+// RunMigrations runs all pending migrations, SQL files and registered Go
+// migrations alike, in the order of their names
 func (m *Migrator) RunMigrations(migrationsPath string) error {
-	// Ensure migrations table exists
-	if err := m.CreateMigrationsTable(); err != nil {
-		return fmt.Errorf("failed to create migrations table: %v", err)
-	}
+	// Keep concurrent deploys from running the same migrations
+	return m.locked(func() error {
+		if err := m.prepare(migrationsPath); err != nil {
+			return err
+		}
+		return m.migrate(migrationsPath)
+	})
+}
 
+// migrate runs the pending migrations, the caller holding the lock
+func (m *Migrator) migrate(migrationsPath string) error {
 	// Get already run migrations
 	runMigrations, err := m.GetRunMigrations()
 	if err != nil {
 		return fmt.Errorf("failed to get run migrations: %v", err)
 	}
 
-	// Get all migration files
-	migrationFiles, err := m.GetMigrationFiles(migrationsPath)
+	// A fresh database starts from the schema dump and the migrations it
+	// records as run
+	loaded, err := m.loadSchemaDump(runMigrations)
+	if err != nil {
+		return err
+	}
+	if loaded && !m.pretend {
+		// Dumps taken before the rename record "_up" and "_down" files
+		if err := m.normalizeMigrationNames(migrationsPath); err != nil {
+			return fmt.Errorf("failed to normalize migration names: %v", err)
+		}
+		if runMigrations, err = m.GetRunMigrations(); err != nil {
+			return fmt.Errorf("failed to get run migrations: %v", err)
+		}
+	}
+
+	// Refuse to continue when applied files were edited afterwards
+	drifted, err := m.DriftedMigrations(migrationsPath, runMigrations)
 	if err != nil {
-		return fmt.Errorf("failed to get migration files: %v", err)
+		return err
+	}
+	if len(drifted) > 0 {
+		return fmt.Errorf("migrations changed after they were run: %s; add a new migration instead",
+			strings.Join(drifted, ", "))
+	}
+
+	// Get all SQL and Go migrations
+	sources, err := m.migrationSources(migrationsPath)
+	if err != nil {
+		return err
 	}
 
 	// Filter out already run migrations
-	pendingMigrations := m.GetPendingMigrations(migrationFiles, runMigrations)
+	ran := make(map[string]bool, len(runMigrations))
+	for _, migration := range runMigrations {
+		ran[migration.Name] = true
+	}
+	var pendingMigrations []migrationSource
+	for _, source := range sources {
+		if !ran[source.name] {
+			pendingMigrations = append(pendingMigrations, source)
+		}
+	}
 	if len(pendingMigrations) == 0 {
 		fmt.Println("No pending migrations.")
 		return nil
@@ -110,120 +297,343 @@ func (m *Migrator) RunMigrations(migrationsPath string) error {
 	}
 
 	// Run each pending migration
-	for _, migrationFile := range pendingMigrations {
-		fmt.Printf("Running migration: %s\n", migrationFile)
+	for _, source := range pendingMigrations {
+		fmt.Printf("Running migration: %s\n", source.name)
 
-		// Read and execute migration SQL
-		if err := m.RunMigrationFile(filepath.Join(migrationsPath, migrationFile), batch); err != nil {
-			return fmt.Errorf("failed to run migration %s: %v", migrationFile, err)
+		if err := m.up(source, batch); err != nil {
+			return fmt.Errorf("failed to run migration %s: %v", source.name, err)
 		}
 	}
 
-	fmt.Printf("Ran %d migrations successfully.\n", len(pendingMigrations))
+	if !m.pretend {
+		fmt.Printf("Ran %d migrations successfully.\n", len(pendingMigrations))
+	}
 	return nil
 }
 
-// RunMigrationFile runs a single migration file
+// RunMigrationFile runs a single migration file and records it with its
+// checksum
 func (m *Migrator) RunMigrationFile(filePath string, batch int) error {
 	// Read SQL file
-	sqlBytes, err := os.ReadFile(filePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	// Split into individual statements
-	sql := string(sqlBytes)
-	statements := strings.Split(sql, ";")
+	// Record migration in database
+	migrationName := MigrationName(filepath.Base(filePath))
+	record := fmt.Sprintf("INSERT INTO migrations (name, batch, checksum) VALUES (%s, %s, %s)",
+		m.dialect.Placeholder(1), m.dialect.Placeholder(2), m.dialect.Placeholder(3))
+	return m.execute(string(content), func(exec Executor) error {
+		_, err := exec.Exec(record, migrationName, batch, Checksum(content))
+		return err
+	})
+}
 
-	// Execute each statement
-	for _, statement := range statements {
-		statement = strings.TrimSpace(statement)
-		if statement == "" {
-			continue
+// execute runs the statements of a script followed by the bookkeeping, in
+// one transaction when the dialect allows it
+func (m *Migrator) execute(script string, bookkeeping func(exec Executor) error) error {
+	statements := SplitStatements(script)
+	if m.pretend {
+		for _, statement := range statements {
+			fmt.Printf("  %s;\n", statement)
 		}
+		return nil
+	}
 
-		if _, err := m.db.Exec(statement); err != nil {
-			return fmt.Errorf("failed to execute SQL: %v\nSQL: %s", err, statement)
+	run := func(exec Executor) error {
+		for _, statement := range statements {
+			if _, err := exec.Exec(statement); err != nil {
+				return fmt.Errorf("failed to execute SQL: %v\nSQL: %s", err, statement)
+			}
 		}
+		return bookkeeping(exec)
+	}
+	if !m.transactional(script) {
+		return run(m.db)
 	}
+	return m.Transaction(func(tx *sql.Tx) error {
+		return run(tx)
+	})
+}
 
-	// Record migration in database
-	migrationName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
-	_, err = m.db.Exec("INSERT INTO migrations (name, batch) VALUES (?, ?)", migrationName, batch)
-	return err
+// transactional reports whether a script can run in a transaction. MySQL
+// commits implicitly on DDL, so a transaction would only pretend to help.
+func (m *Migrator) transactional(script string) bool {
+	return m.dialect.Name() != "mysql" && !strings.Contains(script, NoTransactionMarker)
+}
+
+// Checksum returns the SHA-256 of a migration file, ignoring line ending
+// differences between checkouts
+func Checksum(content []byte) string {
+	normalized := strings.ReplaceAll(string(content), "\r\n", "\n")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// DriftedMigrations returns the applied migrations whose file no longer
+// matches the recorded checksum
+func (m *Migrator) DriftedMigrations(migrationsPath string, runMigrations []Migration) ([]string, error) {
+	files, err := m.GetMigrationFiles(migrationsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to get migration files: %v", err)
+	}
+	byName := make(map[string]string, len(files))
+	for _, file := range files {
+		byName[MigrationName(file)] = file
+	}
+
+	var drifted []string
+	for _, migration := range runMigrations {
+		file, ok := byName[migration.Name]
+		if !ok || migration.Checksum == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(migrationsPath, file))
+		if err != nil {
+			return nil, err
+		}
+		if Checksum(content) != migration.Checksum {
+			drifted = append(drifted, migration.Name)
+		}
+	}
+	return drifted, nil
 }
 
 // RollbackMigrations rolls back the last batch of migrations
 func (m *Migrator) RollbackMigrations(migrationsPath string) error {
-	// Get the last batch number
-	var lastBatch int
-	err := m.db.QueryRow("SELECT MAX(batch) FROM migrations").Scan(&lastBatch)
+	return m.rollbackLocked(migrationsPath, func(run []Migration) []Migration {
+		if len(run) == 0 {
+			return nil
+		}
+		return inBatch(run, run[0].Batch)
+	})
+}
+
+// RollbackSteps rolls back the last steps migrations, whatever their batch
+func (m *Migrator) RollbackSteps(migrationsPath string, steps int) error {
+	return m.rollbackLocked(migrationsPath, func(run []Migration) []Migration {
+		return run[:min(steps, len(run))]
+	})
+}
+
+// RollbackBatch rolls back the migrations of one batch
+func (m *Migrator) RollbackBatch(migrationsPath string, batch int) error {
+	return m.rollbackLocked(migrationsPath, func(run []Migration) []Migration {
+		return inBatch(run, batch)
+	})
+}
+
+// Reset rolls back every migration
+func (m *Migrator) Reset(migrationsPath string) error {
+	return m.rollbackLocked(migrationsPath, func(run []Migration) []Migration {
+		return run
+	})
+}
+
+// Refresh rolls back every migration and runs them all again
+func (m *Migrator) Refresh(migrationsPath string) error {
+	return m.locked(func() error {
+		if err := m.prepare(migrationsPath); err != nil {
+			return err
+		}
+		all := func(run []Migration) []Migration { return run }
+		if err := m.rollback(migrationsPath, all); err != nil {
+			return err
+		}
+		return m.migrate(migrationsPath)
+	})
+}
+
+// Fresh drops every table, including those no migration knows about, and
+// runs all migrations. Unlike Refresh it needs no down files.
+func (m *Migrator) Fresh(migrationsPath string) error {
+	return m.locked(func() error {
+		if err := m.DropAllTables(); err != nil {
+			return err
+		}
+		if err := m.prepare(migrationsPath); err != nil {
+			return err
+		}
+		return m.migrate(migrationsPath)
+	})
+}
+
+// DropAllTables drops every table of the database, foreign keys
+// notwithstanding
+func (m *Migrator) DropAllTables() error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get last batch: %v", err)
+		return err
+	}
+	defer conn.Close()
+
+	var list, before, after string
+	var drop func(table string) []string
+	switch m.dialect.Name() {
+	case "postgres":
+		list = "SELECT tablename FROM pg_tables WHERE schemaname = current_schema()"
+		drop = func(table string) []string {
+			return []string{"DROP TABLE IF EXISTS " + m.dialect.Quote(table) + " CASCADE"}
+		}
+	case "sqlite":
+		list = "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'"
+		before, after = "PRAGMA foreign_keys = OFF", "PRAGMA foreign_keys = ON"
+	case "sqlserver":
+		list = "SELECT name FROM sys.tables"
+		// Constraints referencing the table go first
+		drop = func(table string) []string {
+			return []string{
+				`DECLARE @sql NVARCHAR(MAX) = N'';
+SELECT @sql += N'ALTER TABLE ' + QUOTENAME(OBJECT_SCHEMA_NAME(parent_object_id)) + N'.' +
+	QUOTENAME(OBJECT_NAME(parent_object_id)) + N' DROP CONSTRAINT ' + QUOTENAME(name) + N';'
+FROM sys.foreign_keys WHERE referenced_object_id = OBJECT_ID(N'` + strings.ReplaceAll(table, "'", "''") + `');
+EXEC sp_executesql @sql`,
+				"DROP TABLE " + m.dialect.Quote(table),
+			}
+		}
+	default:
+		list = "SHOW FULL TABLES WHERE Table_type = 'BASE TABLE'"
+		before, after = "SET FOREIGN_KEY_CHECKS = 0", "SET FOREIGN_KEY_CHECKS = 1"
+	}
+	if drop == nil {
+		drop = func(table string) []string {
+			return []string{"DROP TABLE IF EXISTS " + m.dialect.Quote(table)}
+		}
+	}
+
+	tables, err := tableNames(ctx, conn, list)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %v", err)
+	}
+
+	var statements []string
+	if before != "" {
+		statements = append(statements, before)
+	}
+	for _, table := range tables {
+		statements = append(statements, drop(table)...)
+	}
+	if after != "" {
+		statements = append(statements, after)
+	}
+
+	for _, statement := range statements {
+		if m.pretend {
+			fmt.Printf("  %s;\n", statement)
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to execute SQL: %v\nSQL: %s", err, statement)
+		}
+	}
+	if !m.pretend && len(tables) > 0 {
+		fmt.Printf("Dropped %d tables.\n", len(tables))
 	}
+	return nil
+}
 
-	// Get migrations from the last batch
-	rows, err := m.db.Query("SELECT name FROM migrations WHERE batch = ? ORDER BY id DESC", lastBatch)
+// tableNames reads the first column of a table listing; SHOW FULL TABLES
+// adds a second one with the table type
+func tableNames(ctx context.Context, conn *sql.Conn, query string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, query)
 	if err != nil {
-		return fmt.Errorf("failed to get migrations for batch %d: %v", lastBatch, err)
+		return nil, err
 	}
 	defer rows.Close()
 
-	var migrations []string
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
 	for rows.Next() {
 		var name string
-		if err := rows.Scan(&name); err != nil {
+		dest := []any{&name}
+		for range columns[1:] {
+			dest = append(dest, new(sql.RawBytes))
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// locked runs fn holding the migration lock
+func (m *Migrator) locked(fn func() error) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
+func (m *Migrator) rollbackLocked(migrationsPath string, pick func(run []Migration) []Migration) error {
+	return m.locked(func() error {
+		if err := m.prepare(migrationsPath); err != nil {
 			return err
 		}
-		migrations = append(migrations, name)
+		return m.rollback(migrationsPath, pick)
+	})
+}
+
+// rollback runs the down files of the migrations pick chooses from the run
+// migrations, newest first
+func (m *Migrator) rollback(migrationsPath string, pick func(run []Migration) []Migration) error {
+	run, err := m.GetRunMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to get run migrations: %v", err)
+	}
+	for i, j := 0, len(run)-1; i < j; i, j = i+1, j-1 {
+		run[i], run[j] = run[j], run[i]
 	}
 
+	migrations := pick(run)
 	if len(migrations) == 0 {
 		fmt.Println("No migrations to rollback.")
 		return nil
 	}
 
+	sources, err := m.migrationSources(migrationsPath)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]migrationSource, len(sources))
+	for _, source := range sources {
+		byName[source.name] = source
+	}
+
 	// Rollback each migration
 	for _, migration := range migrations {
-		fmt.Printf("Rolling back migration: %s\n", migration)
+		fmt.Printf("Rolling back migration: %s\n", migration.Name)
 
-		// Find and run the down migration file
-		downFile := fmt.Sprintf("%s_down.sql", migration)
-		downPath := filepath.Join(migrationsPath, downFile)
-
-		if _, err := os.Stat(downPath); os.IsNotExist(err) {
-			return fmt.Errorf("down migration file not found: %s", downPath)
+		source, ok := byName[migration.Name]
+		if !ok {
+			return fmt.Errorf("migration %s is neither a file in %s nor registered in Go", migration.Name, migrationsPath)
 		}
-
-		// Read and execute down migration SQL
-		sqlBytes, err := os.ReadFile(downPath)
-		if err != nil {
-			return err
+		if err := m.down(source); err != nil {
+			return fmt.Errorf("failed to roll back %s: %v", migration.Name, err)
 		}
+	}
 
-		sql := string(sqlBytes)
-		statements := strings.Split(sql, ";")
-
-		for _, statement := range statements {
-			statement = strings.TrimSpace(statement)
-			if statement == "" {
-				continue
-			}
-
-			if _, err := m.db.Exec(statement); err != nil {
-				return fmt.Errorf("failed to execute SQL: %v\nSQL: %s", err, statement)
-			}
-		}
+	if !m.pretend {
+		fmt.Printf("Rolled back %d migrations successfully.\n", len(migrations))
+	}
+	return nil
+}
 
-		// Remove migration record
-		_, err = m.db.Exec("DELETE FROM migrations WHERE name = ?", migration)
-		if err != nil {
-			return fmt.Errorf("failed to delete migration record: %v", err)
+func inBatch(run []Migration, batch int) []Migration {
+	var migrations []Migration
+	for _, migration := range run {
+		if migration.Batch == batch {
+			migrations = append(migrations, migration)
 		}
 	}
-
-	fmt.Printf("Rolled back %d migrations successfully.\n", len(migrations))
-	return nil
+	return migrations
 }
 
 // GetMigrationFiles gets all migration files in the directory
@@ -233,9 +643,10 @@ func (m *Migrator) GetMigrationFiles(migrationsPath string) ([]string, error) {
 		return nil, err
 	}
 
+	// Down files are only read when rolling back
 	var migrations []string
 	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".sql") {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".sql") && !strings.HasSuffix(file.Name(), "_down.sql") {
 			migrations = append(migrations, file.Name())
 		}
 	}
@@ -249,15 +660,23 @@ func (m *Migrator) GetMigrationFiles(migrationsPath string) ([]string, error) {
 func (m *Migrator) GetPendingMigrations(allMigrations []string, runMigrations []Migration) []string {
 	runMap := make(map[string]bool)
 	for _, migration := range runMigrations {
-		runMap[migration.Name+".sql"] = true
+		runMap[migration.Name] = true
 	}
 
 	var pending []string
 	for _, migration := range allMigrations {
-		if !runMap[migration] {
+		if !runMap[MigrationName(migration)] {
 			pending = append(pending, migration)
 		}
 	}
 
 	return pending
 }
+
+// MigrationName is the name a migration file is recorded under: the file
+// name without ".sql" and the "_up" of an up/down pair, so
+// "20250101_create_posts_up.sql" is "20250101_create_posts" and rolls back
+// with "20250101_create_posts_down.sql"
+func MigrationName(file string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(file, ".sql"), "_up")
+}
@@ -0,0 +1,271 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newSQLiteMigrator returns a migrator on a fresh sqlite database and an
+// empty migrations directory
+func newSQLiteMigrator(t *testing.T) (*Migrator, *sql.DB, string) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations := filepath.Join(dir, "migrations")
+	if err := os.Mkdir(migrations, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return NewMigrator(db).UseDialect(SQLiteDialect{}), db, migrations
+}
+
+func writeMigration(t *testing.T, dir, name, up, down string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+"_up.sql"), []byte(up), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if down != "" {
+		if err := os.WriteFile(filepath.Join(dir, name+"_down.sql"), []byte(down), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func hasTable(t *testing.T, db *sql.DB, table string) bool {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func statusLines(t *testing.T, m *Migrator, dir string) []string {
+	t.Helper()
+	statuses, err := m.Status(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make([]string, len(statuses))
+	for i, status := range statuses {
+		lines[i] = status.String()
+	}
+	return lines
+}
+
+func TestMigrateRollbackAndStatus(t *testing.T) {
+	m, db, dir := newSQLiteMigrator(t)
+	writeMigration(t, dir, "20250101000000_create_users",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);",
+		"DROP TABLE users;")
+
+	if err := m.RunMigrations(dir); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	writeMigration(t, dir, "20250102000000_create_posts",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT);\nINSERT INTO posts (title) VALUES ('a; b');",
+		"DROP TABLE posts;")
+	if got, want := statusLines(t, m, dir), []string{
+		"Ran     20250101000000_create_users (batch 1)",
+		"Pending 20250102000000_create_posts",
+	}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("status before second migrate:\ngot  %q\nwant %q", got, want)
+	}
+
+	if err := m.RunMigrations(dir); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if !hasTable(t, db, "users") || !hasTable(t, db, "posts") {
+		t.Fatal("migrate did not create the tables")
+	}
+	var title string
+	if err := db.QueryRow("SELECT title FROM posts").Scan(&title); err != nil || title != "a; b" {
+		t.Fatalf("seeded title = %q, %v; the semicolon in the literal split the statement", title, err)
+	}
+
+	// Rollback reverts the last batch only
+	if err := m.RollbackMigrations(dir); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if hasTable(t, db, "posts") || !hasTable(t, db, "users") {
+		t.Fatal("rollback did not revert exactly the last batch")
+	}
+	if got, want := statusLines(t, m, dir), []string{
+		"Ran     20250101000000_create_users (batch 1)",
+		"Pending 20250102000000_create_posts",
+	}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("status after rollback:\ngot  %q\nwant %q", got, want)
+	}
+
+	if err := m.Reset(dir); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if hasTable(t, db, "users") {
+		t.Fatal("reset left the users table")
+	}
+}
+
+func TestFailedMigrationIsRolledBack(t *testing.T) {
+	m, db, dir := newSQLiteMigrator(t)
+	writeMigration(t, dir, "20250101000000_broken",
+		"CREATE TABLE half (id INTEGER);\nINSERT INTO missing_table VALUES (1);", "")
+
+	if err := m.RunMigrations(dir); err == nil {
+		t.Fatal("migrate succeeded with a failing statement")
+	}
+	if hasTable(t, db, "half") {
+		t.Fatal("the statements before the failure were not rolled back")
+	}
+	if got := statusLines(t, m, dir); !reflect.DeepEqual(got, []string{"Pending 20250101000000_broken"}) {
+		t.Fatalf("failed migration was recorded: %q", got)
+	}
+}
+
+func TestChangedMigrationStopsMigrate(t *testing.T) {
+	m, _, dir := newSQLiteMigrator(t)
+	writeMigration(t, dir, "20250101000000_create_users", "CREATE TABLE users (id INTEGER);", "DROP TABLE users;")
+	if err := m.RunMigrations(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	writeMigration(t, dir, "20250101000000_create_users", "CREATE TABLE users (id INTEGER, email TEXT);", "DROP TABLE users;")
+	writeMigration(t, dir, "20250102000000_create_posts", "CREATE TABLE posts (id INTEGER);", "")
+	err := m.RunMigrations(dir)
+	if err == nil || !strings.Contains(err.Error(), "20250101000000_create_users") {
+		t.Fatalf("migrate after editing an applied file: got %v", err)
+	}
+	if got := statusLines(t, m, dir)[0]; got != "Ran     20250101000000_create_users (batch 1, modified)" {
+		t.Fatalf("status of the edited migration: %q", got)
+	}
+}
+
+func TestPretendRunsNothing(t *testing.T) {
+	m, db, dir := newSQLiteMigrator(t)
+	writeMigration(t, dir, "20250101000000_create_users", "CREATE TABLE users (id INTEGER);", "")
+
+	if err := m.Pretend(true).RunMigrations(dir); err != nil {
+		t.Fatal(err)
+	}
+	if hasTable(t, db, "users") {
+		t.Fatal("--pretend created the table")
+	}
+	if got := statusLines(t, m, dir); !reflect.DeepEqual(got, []string{"Pending 20250101000000_create_users"}) {
+		t.Fatalf("--pretend recorded the migration: %q", got)
+	}
+}
+
+func TestLegacyMigrationNamesAreRenamed(t *testing.T) {
+	m, db, dir := newSQLiteMigrator(t)
+	writeMigration(t, dir, "20250823222812_flexiload", "CREATE TABLE flexiload (id TEXT);", "DROP TABLE flexiload;")
+
+	// A table from before checksums, recording every file by its name
+	for _, statement := range []string{
+		"CREATE TABLE migrations (id INTEGER PRIMARY KEY AUTOINCREMENT, name VARCHAR(255) NOT NULL UNIQUE, batch INTEGER NOT NULL, created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)",
+		"CREATE TABLE flexiload (id TEXT)",
+		"INSERT INTO migrations (name, batch) VALUES ('20250823222812_flexiload_up', 1), ('20250823222812_flexiload_down', 1)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.RunMigrations(dir); err != nil {
+		t.Fatalf("migrate on a legacy table: %v", err)
+	}
+	if got := statusLines(t, m, dir); !reflect.DeepEqual(got, []string{"Ran     20250823222812_flexiload (batch 1)"}) {
+		t.Fatalf("legacy records were not renamed: %q", got)
+	}
+	if err := m.RollbackMigrations(dir); err != nil {
+		t.Fatalf("rollback of a renamed record: %v", err)
+	}
+	if hasTable(t, db, "flexiload") {
+		t.Fatal("rollback did not run the down file")
+	}
+}
+
+func TestNamesEndingInUpWithoutLegacyFileAreKept(t *testing.T) {
+	m, db, dir := newSQLiteMigrator(t)
+	RegisterMigration(GoMigration{
+		Name: "20250101000000_sign_up",
+		Up: func(exec Executor, dialect Dialect) error {
+			_, err := exec.Exec("CREATE TABLE sign_ups (id INTEGER)")
+			return err
+		},
+	})
+	t.Cleanup(func() {
+		goMigrationsMu.Lock()
+		delete(goMigrations, "20250101000000_sign_up")
+		goMigrationsMu.Unlock()
+	})
+
+	if err := m.RunMigrations(dir); err != nil {
+		t.Fatal(err)
+	}
+	// A record of a pruned file, which normalizing cannot match to a file
+	if _, err := db.Exec("INSERT INTO migrations (name, batch) VALUES ('20240101000000_pruned_down', 1)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second run would fail on CREATE TABLE if the record were renamed
+	if err := m.RunMigrations(dir); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	if got, want := statusLines(t, m, dir), []string{
+		"Ran     20240101000000_pruned_down (batch 1, missing)",
+		"Ran     20250101000000_sign_up (batch 1, go)",
+	}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("records were rewritten:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "literals and comments",
+			script: "INSERT INTO t VALUES ('a;b'); -- done;\n/* c; */ SELECT \"x;y\" FROM t;",
+			want:   []string{"INSERT INTO t VALUES ('a;b')", "-- done;\n/* c; */ SELECT \"x;y\" FROM t"},
+		},
+		{
+			name:   "postgres dollar quotes",
+			script: "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\nSELECT f();",
+			want:   []string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql", "SELECT f()"},
+		},
+		{
+			name:   "trigger body",
+			script: "CREATE TRIGGER tr AFTER INSERT ON t BEGIN UPDATE c SET n = n + 1; END;\nSELECT 1;",
+			want:   []string{"CREATE TRIGGER tr AFTER INSERT ON t BEGIN UPDATE c SET n = n + 1; END", "SELECT 1"},
+		},
+		{
+			name:   "mysql delimiter",
+			script: "DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END //\nDELIMITER ;\nSELECT 2;",
+			want:   []string{"CREATE PROCEDURE p() BEGIN SELECT 1; END", "SELECT 2"},
+		},
+		{
+			name:   "sqlserver batches",
+			script: "CREATE TABLE t (id INT)\nGO\nINSERT INTO t VALUES (1)\nGO\n",
+			want:   []string{"CREATE TABLE t (id INT)", "INSERT INTO t VALUES (1)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// pkg/database/model.go
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Timestamp columns maintained by Create, Update and Upsert
+const (
+	createdAtColumn = "created_at"
+	updatedAtColumn = "updated_at"
+)
+
+// timePrecision is what DATETIME and TIMESTAMP columns keep by default, so
+// a time read back compares equal to the one written
+const timePrecision = time.Second
+
+// KeyedModel is implemented by models whose primary key column is not "id"
+type KeyedModel interface {
+	Model
+	PrimaryKey() string
+}
+
+func primaryKey(model Model) string {
+	if keyed, ok := model.(KeyedModel); ok {
+		return keyed.PrimaryKey()
+	}
+	return "id"
+}
+
+// primaryKeyValue returns the key of a model, false when it is zero
+func primaryKeyValue(model Model, val reflect.Value) (interface{}, bool) {
+	idx, ok := columnIndex(val.Type())[primaryKey(model)]
+	if !ok {
+		return nil, false
+	}
+	fv := val.FieldByIndex(idx)
+	if fv.IsZero() {
+		return nil, false
+	}
+	return fv.Interface(), true
+}
+
+func modelValue(model Model) (reflect.Value, error) {
+	rv := reflect.ValueOf(model)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("database: expected a pointer to a struct model, got %T", model)
+	}
+	return rv.Elem(), nil
+}
+
+// touch sets a time.Time or *time.Time column to now, at column precision,
+// only when it is still zero unless force is set. It reports whether the
+// model has the column.
+func touch(val reflect.Value, column string, now time.Time, force bool) bool {
+	idx, ok := columnIndex(val.Type())[column]
+	if !ok {
+		return false
+	}
+
+	now = now.Truncate(timePrecision)
+	fv := val.FieldByIndex(idx)
+	switch fv.Type() {
+	case reflect.TypeOf(time.Time{}):
+		if force || fv.IsZero() {
+			fv.Set(reflect.ValueOf(now))
+		}
+	case reflect.TypeOf(&time.Time{}):
+		if force || fv.IsNil() {
+			fv.Set(reflect.ValueOf(&now))
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func isInteger(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func setInteger(v reflect.Value, n int64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(n))
+	}
+}
+
+// equalValues compares a field with its stored value; times are compared as
+// instants at column precision since the database drops the monotonic
+// clock, the location and the fraction of a second
+func equalValues(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && equalTimes(ta, tb)
+	}
+	if ta, ok := a.(*time.Time); ok {
+		tb, ok := b.(*time.Time)
+		if !ok || ta == nil || tb == nil {
+			return ok && ta == tb
+		}
+		return equalTimes(*ta, *tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func equalTimes(a, b time.Time) bool {
+	return a.Truncate(timePrecision).Equal(b.Truncate(timePrecision))
+}
+
+// wrapQueryError adds the SQL to an error, which errors.Is still matches,
+// e.g. against sql.ErrNoRows
+func wrapQueryError(err error, query string) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("database: %w\nSQL: %s", err, query)
+}
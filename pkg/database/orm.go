@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 )
 
 type Model interface {
@@ -12,51 +13,387 @@ type Model interface {
 }
 
 type ORM struct {
-	db *sql.DB
+	db          Executor // conn, or the transaction inside Transaction
+	conn        *sql.DB
+	reader      Executor // SELECTs outside transactions, see UseReader
+	tx          *txState
+	dialect     Dialect
+	preventLazy bool
+	observers   *Observers
+	scopes      *scopeRegistry
 }
 
+// NewORM creates an ORM for a MySQL connection, see UseDialect for others
 func NewORM(db *sql.DB) *ORM {
-	return &ORM{db: db}
+	return &ORM{
+		db:        db,
+		conn:      db,
+		dialect:   MySQLDialect{},
+		observers: DefaultObservers,
+		scopes:    &scopeRegistry{scopes: make(map[string]map[string]Scope)},
+	}
+}
+
+// UseDialect switches the SQL dialect, e.g. orm.UseDialect(DialectFor("postgres"))
+func (o *ORM) UseDialect(dialect Dialect) *ORM {
+	o.dialect = dialect
+	return o
+}
+
+// UseReader sends the SELECTs of queries run outside a transaction to
+// reader, e.g. a read replica. Writes, transactions and the reads Save and
+// Update make to compare with the stored row stay on the connection.
+func (o *ORM) UseReader(reader Executor) *ORM {
+	o.reader = reader
+	return o
+}
+
+// OnPrimary returns a copy of the ORM that reads from the connection too,
+// to read a row right after writing it when a replica may lag behind
+func (o *ORM) OnPrimary() *ORM {
+	primary := *o
+	primary.reader = nil
+	return &primary
 }
 
+// reads returns where SELECTs go
+func (o *ORM) reads() Executor {
+	if o.reader != nil && o.tx == nil {
+		return o.reader
+	}
+	return o.db
+}
+
+// Dialect returns the SQL dialect of the connection
+func (o *ORM) Dialect() Dialect {
+	return o.dialect
+}
+
+// Create inserts a model, zero values included. A zero integer primary key
+// is left to the database and filled in afterwards; created_at and
+// updated_at are set when the model has them.
 func (o *ORM) Create(model Model) error {
-	val := reflect.ValueOf(model).Elem()
-	typ := val.Type()
+	if _, err := modelValue(model); err != nil {
+		return err
+	}
+	if err := o.fire(eventSaving, model); err != nil {
+		return err
+	}
+	if err := o.fire(eventCreating, model); err != nil {
+		return err
+	}
+	if err := o.insert(model); err != nil {
+		return err
+	}
+	if err := o.fire(eventCreated, model); err != nil {
+		return err
+	}
+	return o.fire(eventSaved, model)
+}
+
+func (o *ORM) insert(model Model) error {
+	val, err := modelValue(model)
+	if err != nil {
+		return err
+	}
 
-	fields := []string{}
-	placeholders := []string{}
-	values := []interface{}{}
+	now := time.Now()
+	touch(val, createdAtColumn, now, false)
+	touch(val, updatedAtColumn, now, true)
 
-	for i := 0; i < val.NumField(); i++ {
-		field := typ.Field(i)
-		if field.Tag.Get("db") != "" && !val.Field(i).IsZero() {
-			fields = append(fields, field.Tag.Get("db"))
-			placeholders = append(placeholders, "?")
-			values = append(values, val.Field(i).Interface())
+	pk := primaryKey(model)
+	autoIncrement := false
+	var columns []string
+	var values []interface{}
+	for _, f := range modelFields(val.Type()) {
+		fv := val.FieldByIndex(f.index)
+		if f.column == pk && fv.IsZero() && isInteger(fv) {
+			autoIncrement = true
+			continue
 		}
+		columns = append(columns, f.column)
+		values = append(values, fv.Interface())
+	}
+
+	b := &binder{dialect: o.dialect}
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = b.bind(v)
 	}
 
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		model.TableName(),
-		strings.Join(fields, ", "),
-		strings.Join(placeholders, ", "))
+	table := quoteColumn(o.dialect, model.TableName())
+	query := fmt.Sprintf("INSERT INTO %s (%s)", table, o.quoteColumns(columns))
+	if !autoIncrement {
+		query += fmt.Sprintf(" VALUES (%s)", strings.Join(placeholders, ", "))
+		_, err := o.db.Exec(query, b.args...)
+		return wrapQueryError(err, query)
+	}
+
+	// The generated key comes back differently per driver
+	pkField := val.FieldByIndex(columnIndex(val.Type())[pk])
+	switch o.dialect.Name() {
+	case "postgres":
+		query += fmt.Sprintf(" VALUES (%s) RETURNING %s", strings.Join(placeholders, ", "), o.dialect.Quote(pk))
+		return wrapQueryError(o.db.QueryRow(query, b.args...).Scan(pkField.Addr().Interface()), query)
+	case "sqlserver":
+		query += fmt.Sprintf(" OUTPUT INSERTED.%s VALUES (%s)", o.dialect.Quote(pk), strings.Join(placeholders, ", "))
+		return wrapQueryError(o.db.QueryRow(query, b.args...).Scan(pkField.Addr().Interface()), query)
+	}
 
-	_, err := o.db.Exec(query, values...)
-	return err
+	query += fmt.Sprintf(" VALUES (%s)", strings.Join(placeholders, ", "))
+	result, err := o.db.Exec(query, b.args...)
+	if err != nil {
+		return wrapQueryError(err, query)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	setInteger(pkField, id)
+	return nil
+}
+
+// Find loads a model by primary key, matching columns to db tags by name.
+// It returns sql.ErrNoRows when there is no such row.
+func (o *ORM) Find(model Model, id interface{}) error {
+	return o.Model(model).Where(primaryKey(model), "=", id).First(model)
+}
+
+// Save creates the model when its primary key is zero or not in the table
+// yet, and updates it otherwise
+func (o *ORM) Save(model Model) error {
+	val, err := modelValue(model)
+	if err != nil {
+		return err
+	}
+
+	id, ok := primaryKeyValue(model, val)
+	if !ok {
+		return o.Create(model)
+	}
+	exists, err := o.OnPrimary().Model(model).WithTrashed().Where(primaryKey(model), "=", id).Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return o.Create(model)
+	}
+	return o.Update(model)
 }
 
-func (o *ORM) Find(model Model, id int) error {
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id = ?", model.TableName())
-	row := o.db.QueryRow(query, id)
+// Update writes the columns that differ from the stored row, plus
+// updated_at. Nothing is written when no column changed, and the update
+// hooks only run when something did.
+func (o *ORM) Update(model Model) error {
+	val, err := modelValue(model)
+	if err != nil {
+		return err
+	}
+
+	pk := primaryKey(model)
+	id, ok := primaryKeyValue(model, val)
+	if !ok {
+		return fmt.Errorf("database: update of %s without a primary key", model.TableName())
+	}
 
-	val := reflect.ValueOf(model).Elem()
-	fields := make([]interface{}, val.NumField())
+	current := reflect.New(val.Type())
+	if err := o.OnPrimary().Model(model).WithTrashed().Where(pk, "=", id).First(current.Interface()); err != nil {
+		return err
+	}
 
-	for i := 0; i < val.NumField(); i++ {
-		fields[i] = val.Field(i).Addr().Interface()
+	if err := o.fire(eventSaving, model); err != nil {
+		return err
 	}
+	if len(dirtyColumns(model, val, current.Elem())) > 0 {
+		if err := o.fire(eventUpdating, model); err != nil {
+			return err
+		}
+
+		// Hooks may have changed the model, so diff again
+		dirty := dirtyColumns(model, val, current.Elem())
+		if touch(val, updatedAtColumn, time.Now(), true) {
+			dirty[updatedAtColumn] = val.FieldByIndex(columnIndex(val.Type())[updatedAtColumn]).Interface()
+		}
+		if _, err := o.Model(model).WithTrashed().Where(pk, "=", id).Update(dirty); err != nil {
+			return err
+		}
 
-	return row.Scan(fields...)
+		if err := o.fire(eventUpdated, model); err != nil {
+			return err
+		}
+	}
+	return o.fire(eventSaved, model)
 }
 
-// Add Update, Delete, Where methods...
+// dirtyColumns returns the columns whose value differs from the stored row
+func dirtyColumns(model Model, val, current reflect.Value) map[string]interface{} {
+	pk := primaryKey(model)
+	dirty := make(map[string]interface{})
+	for _, f := range modelFields(val.Type()) {
+		if f.column == pk || f.column == updatedAtColumn {
+			continue
+		}
+		value := val.FieldByIndex(f.index).Interface()
+		if !equalValues(value, current.FieldByIndex(f.index).Interface()) {
+			dirty[f.column] = value
+		}
+	}
+	return dirty
+}
+
+// Delete removes the model's row by primary key, or sets deleted_at for
+// models that embed SoftDeletes
+func (o *ORM) Delete(model Model) error {
+	if _, err := modelValue(model); err != nil {
+		return err
+	}
+	if err := o.fire(eventDeleting, model); err != nil {
+		return err
+	}
+	if err := o.deleteRow(model); err != nil {
+		return err
+	}
+	return o.fire(eventDeleted, model)
+}
+
+func (o *ORM) deleteRow(model Model) error {
+	val, err := modelValue(model)
+	if err != nil {
+		return err
+	}
+
+	id, ok := primaryKeyValue(model, val)
+	if !ok {
+		return fmt.Errorf("database: delete of %s without a primary key", model.TableName())
+	}
+	if !isSoftDeletable(val.Type()) {
+		_, err = o.Model(model).Where(primaryKey(model), "=", id).Delete()
+		return err
+	}
+
+	now := time.Now()
+	if _, err := o.Model(model).Where(primaryKey(model), "=", id).Update(map[string]interface{}{deletedAtColumn: now}); err != nil {
+		return err
+	}
+	val.FieldByIndex(columnIndex(val.Type())[deletedAtColumn]).Set(reflect.ValueOf(&now))
+	return nil
+}
+
+// Upsert inserts the model or, when a row with the same conflict columns
+// exists, updates it. updateColumns defaults to every column except the
+// conflict columns and created_at. Upsert runs no hooks, since it cannot
+// tell whether the row was inserted or updated.
+//
+//	orm.Upsert(&product, []string{"sku"})
+func (o *ORM) Upsert(model Model, conflict []string, updateColumns ...string) error {
+	val, err := modelValue(model)
+	if err != nil {
+		return err
+	}
+	if len(conflict) == 0 {
+		return fmt.Errorf("database: upsert on %s without conflict columns", model.TableName())
+	}
+
+	now := time.Now()
+	touch(val, createdAtColumn, now, false)
+	touch(val, updatedAtColumn, now, true)
+
+	var columns []string
+	var values []interface{}
+	for _, f := range modelFields(val.Type()) {
+		fv := val.FieldByIndex(f.index)
+		if f.column == primaryKey(model) && fv.IsZero() && isInteger(fv) {
+			continue
+		}
+		columns = append(columns, f.column)
+		values = append(values, fv.Interface())
+	}
+
+	if len(updateColumns) == 0 {
+		skip := map[string]bool{createdAtColumn: true}
+		for _, c := range conflict {
+			skip[c] = true
+		}
+		for _, c := range columns {
+			if !skip[c] {
+				updateColumns = append(updateColumns, c)
+			}
+		}
+	}
+	for _, c := range append(append([]string(nil), conflict...), updateColumns...) {
+		if !identifierRegex.MatchString(c) {
+			return fmt.Errorf("database: invalid identifier %q", c)
+		}
+	}
+
+	query, args := o.upsertSQL(model.TableName(), columns, values, conflict, updateColumns)
+	_, err = o.db.Exec(query, args...)
+	return wrapQueryError(err, query)
+}
+
+func (o *ORM) upsertSQL(table string, columns []string, values []interface{}, conflict, update []string) (string, []interface{}) {
+	d := o.dialect
+	b := &binder{dialect: d}
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = b.bind(v)
+	}
+
+	quotedTable := quoteColumn(d, table)
+	sets := make([]string, len(update))
+
+	switch d.Name() {
+	case "mysql":
+		for i, c := range update {
+			sets[i] = fmt.Sprintf("%s = VALUES(%s)", d.Quote(c), d.Quote(c))
+		}
+		if len(sets) == 0 {
+			// Nothing to update: a no-op assignment keeps the existing row
+			// without INSERT IGNORE hiding other errors
+			sets = []string{fmt.Sprintf("%s = %s", d.Quote(conflict[0]), d.Quote(conflict[0]))}
+		}
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+			quotedTable, o.quoteColumns(columns), strings.Join(placeholders, ", "), strings.Join(sets, ", ")), b.args
+
+	case "sqlserver":
+		source := make([]string, len(columns))
+		inserted := make([]string, len(columns))
+		for i, c := range columns {
+			source[i] = placeholders[i] + " AS " + d.Quote(c)
+			inserted[i] = "source." + d.Quote(c)
+		}
+		on := make([]string, len(conflict))
+		for i, c := range conflict {
+			on[i] = fmt.Sprintf("target.%s = source.%s", d.Quote(c), d.Quote(c))
+		}
+		for i, c := range update {
+			sets[i] = fmt.Sprintf("target.%s = source.%s", d.Quote(c), d.Quote(c))
+		}
+		query := fmt.Sprintf("MERGE INTO %s WITH (HOLDLOCK) AS target USING (SELECT %s) AS source ON %s",
+			quotedTable, strings.Join(source, ", "), strings.Join(on, " AND "))
+		if len(sets) > 0 {
+			query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(sets, ", ")
+		}
+		query += fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);", o.quoteColumns(columns), strings.Join(inserted, ", "))
+		return query, b.args
+	}
+
+	// postgres and sqlite
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s)",
+		quotedTable, o.quoteColumns(columns), strings.Join(placeholders, ", "), o.quoteColumns(conflict))
+	if len(update) == 0 {
+		return query + " DO NOTHING", b.args
+	}
+	for i, c := range update {
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", d.Quote(c), d.Quote(c))
+	}
+	return query + " DO UPDATE SET " + strings.Join(sets, ", "), b.args
+}
+
+func (o *ORM) quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = o.dialect.Quote(c)
+	}
+	return strings.Join(quoted, ", ")
+}
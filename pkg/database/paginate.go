@@ -0,0 +1,403 @@
+// pkg/database/paginate.go
+package database
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPerPage caps the per_page a request can ask for
+var MaxPerPage = 100
+
+// Paginator is one page of a query with the numbers needed to render links.
+// It marshals to the JSON envelope used by the API:
+//
+//	{"data": [...], "meta": {"current_page": 2, ...}, "links": {"next": "...", ...}}
+type Paginator struct {
+	Items       interface{}
+	Total       int64
+	PerPage     int
+	CurrentPage int
+	LastPage    int
+	From        int // position of the first item, 0 when the page is empty
+	To          int
+
+	Path  string     // base URL of the links
+	Query url.Values // query string kept in the links, e.g. filters
+}
+
+// PageLink is one entry of Paginator.Links; a zero Page is a "..." gap
+type PageLink struct {
+	Page   int
+	URL    string
+	Label  string
+	Active bool
+}
+
+// Paginate runs the query for one page into dest, a pointer to a slice,
+// and counts the total
+//
+//	var posts []models.Post
+//	page, err := orm.Model(&models.Post{}).OrderBy("created_at", "desc").Paginate(2, 15, &posts)
+func (q *Query) Paginate(page, perPage int, dest interface{}) (*Paginator, error) {
+	if perPage < 1 {
+		perPage = 15
+	}
+	if page < 1 {
+		page = 1
+	}
+	q.modelFrom(dest)
+
+	total, err := q.Count()
+	if err != nil {
+		return nil, err
+	}
+
+	paged := *q
+	paged.limit, paged.offset = perPage, (page-1)*perPage
+	if err := paged.Get(dest); err != nil {
+		return nil, err
+	}
+
+	p := &Paginator{
+		Items:       dest,
+		Total:       total,
+		PerPage:     perPage,
+		CurrentPage: page,
+		LastPage:    max(1, int((total+int64(perPage)-1)/int64(perPage))),
+	}
+	if n := reflect.ValueOf(dest).Elem().Len(); n > 0 {
+		p.From = (page-1)*perPage + 1
+		p.To = p.From + n - 1
+	}
+	return p, nil
+}
+
+// PaginateRequest reads ?page= and ?per_page= from the request and builds
+// links relative to its URL:
+//
+//	page, err := query.PaginateRequest(ctx.Request, 15, &posts)
+func (q *Query) PaginateRequest(r *http.Request, perPage int, dest interface{}) (*Paginator, error) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	if n, err := strconv.Atoi(query.Get("per_page")); err == nil && n > 0 {
+		perPage = min(n, MaxPerPage)
+	}
+
+	p, err := q.Paginate(page, perPage, dest)
+	if err != nil {
+		return nil, err
+	}
+	p.Path = r.URL.Path
+	p.Query = query
+	return p, nil
+}
+
+// URL returns the link of a page, keeping the other query parameters
+func (p *Paginator) URL(page int) string {
+	return pageURL(p.Path, p.Query, "page", strconv.Itoa(page))
+}
+
+func (p *Paginator) HasPages() bool     { return p.LastPage > 1 }
+func (p *Paginator) OnFirstPage() bool  { return p.CurrentPage <= 1 }
+func (p *Paginator) HasMorePages() bool { return p.CurrentPage < p.LastPage }
+
+// PrevURL returns the previous page link, "" on the first page
+func (p *Paginator) PrevURL() string {
+	if p.OnFirstPage() {
+		return ""
+	}
+	return p.URL(p.CurrentPage - 1)
+}
+
+// NextURL returns the next page link, "" on the last page
+func (p *Paginator) NextURL() string {
+	if !p.HasMorePages() {
+		return ""
+	}
+	return p.URL(p.CurrentPage + 1)
+}
+
+// Links returns the numbered links: the first and last two pages and
+// three on each side of the current one, with gaps in between
+func (p *Paginator) Links() []PageLink {
+	const window = 3
+
+	var links []PageLink
+	gap := false
+	for page := 1; page <= p.LastPage; page++ {
+		near := page >= p.CurrentPage-window && page <= p.CurrentPage+window
+		edge := page <= 2 || page > p.LastPage-2
+		if !near && !edge {
+			if !gap {
+				links = append(links, PageLink{Label: "..."})
+				gap = true
+			}
+			continue
+		}
+		gap = false
+		links = append(links, PageLink{
+			Page:   page,
+			URL:    p.URL(page),
+			Label:  strconv.Itoa(page),
+			Active: page == p.CurrentPage,
+		})
+	}
+	return links
+}
+
+func (p *Paginator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"data": p.Items,
+		"meta": map[string]interface{}{
+			"current_page": p.CurrentPage,
+			"per_page":     p.PerPage,
+			"total":        p.Total,
+			"last_page":    p.LastPage,
+			"from":         p.From,
+			"to":           p.To,
+		},
+		"links": map[string]interface{}{
+			"first": p.URL(1),
+			"last":  p.URL(p.LastPage),
+			"prev":  nullable(p.PrevURL()),
+			"next":  nullable(p.NextURL()),
+		},
+	})
+}
+
+// CursorPaginator is a page of keyset pagination. It skips the COUNT and
+// the OFFSET scan, so it stays fast on large tables, but only links to the
+// neighbouring pages.
+type CursorPaginator struct {
+	Items      interface{}
+	PerPage    int
+	NextCursor string
+	PrevCursor string
+
+	Path  string
+	Query url.Values
+}
+
+type cursor struct {
+	Value interface{} `json:"v"`
+	Time  bool        `json:"t,omitempty"` // Value is a time.Time in RFC 3339
+	Prev  bool        `json:"p,omitempty"`
+}
+
+// CursorPaginate reads the page after (or before) cursor, ordered by a
+// unique column. Pass "" for the first page.
+//
+//	page, err := orm.Model(&models.Post{}).CursorPaginate(ctx.Request.URL.Query().Get("cursor"), 20, "id", "desc", &posts)
+func (q *Query) CursorPaginate(encoded string, perPage int, column, direction string, dest interface{}) (*CursorPaginator, error) {
+	if perPage < 1 {
+		perPage = 15
+	}
+	dir := strings.ToLower(direction)
+	if dir != "asc" && dir != "desc" {
+		return nil, fmt.Errorf("database: invalid cursor direction %q", direction)
+	}
+
+	var c cursor
+	if encoded != "" {
+		var err error
+		if c, err = decodeCursor(encoded); err != nil {
+			return nil, err
+		}
+	}
+
+	// Going backwards reads in the opposite order and flips the result
+	order, op := dir, ">"
+	if dir == "desc" {
+		op = "<"
+	}
+	if c.Prev {
+		order = map[string]string{"asc": "desc", "desc": "asc"}[dir]
+		op = map[string]string{">": "<", "<": ">"}[op]
+	}
+
+	// Group the caller's wheres so an OrWhere cannot escape the cursor
+	page := *q
+	page.wheres = nil
+	if len(q.wheres) > 0 {
+		page.wheres = []whereClause{{boolean: "AND", kind: "group", nested: q.wheres}}
+	}
+	page.orders = nil
+	if c.Value != nil {
+		page.Where(column, op, c.Value)
+	}
+	page.OrderBy(column, order).Limit(perPage + 1)
+	if err := page.Get(dest); err != nil {
+		return nil, err
+	}
+
+	items := reflect.ValueOf(dest).Elem()
+	hasMore := items.Len() > perPage
+	if hasMore {
+		items.Set(items.Slice(0, perPage))
+	}
+	if c.Prev {
+		reverse(items)
+	}
+
+	p := &CursorPaginator{Items: dest, PerPage: perPage}
+	if items.Len() == 0 {
+		return p, nil
+	}
+	first, last := cursorValue(items.Index(0), column), cursorValue(items.Index(items.Len()-1), column)
+
+	// Moving forward there is a previous page whenever we came from a
+	// cursor; moving back there is always a next page
+	if c.Prev {
+		p.NextCursor = encodeCursor(cursor{Value: last})
+		if hasMore {
+			p.PrevCursor = encodeCursor(cursor{Value: first, Prev: true})
+		}
+	} else {
+		if hasMore {
+			p.NextCursor = encodeCursor(cursor{Value: last})
+		}
+		if c.Value != nil {
+			p.PrevCursor = encodeCursor(cursor{Value: first, Prev: true})
+		}
+	}
+	return p, nil
+}
+
+// CursorPaginateRequest reads ?cursor= and ?per_page= from the request
+func (q *Query) CursorPaginateRequest(r *http.Request, perPage int, column, direction string, dest interface{}) (*CursorPaginator, error) {
+	query := r.URL.Query()
+	if n, err := strconv.Atoi(query.Get("per_page")); err == nil && n > 0 {
+		perPage = min(n, MaxPerPage)
+	}
+
+	p, err := q.CursorPaginate(query.Get("cursor"), perPage, column, direction, dest)
+	if err != nil {
+		return nil, err
+	}
+	p.Path = r.URL.Path
+	p.Query = query
+	return p, nil
+}
+
+// NextURL returns the next page link, "" on the last page
+func (p *CursorPaginator) NextURL() string {
+	if p.NextCursor == "" {
+		return ""
+	}
+	return pageURL(p.Path, p.Query, "cursor", p.NextCursor)
+}
+
+// PrevURL returns the previous page link, "" on the first page
+func (p *CursorPaginator) PrevURL() string {
+	if p.PrevCursor == "" {
+		return ""
+	}
+	return pageURL(p.Path, p.Query, "cursor", p.PrevCursor)
+}
+
+func (p *CursorPaginator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"data": p.Items,
+		"meta": map[string]interface{}{
+			"per_page":    p.PerPage,
+			"next_cursor": nullable(p.NextCursor),
+			"prev_cursor": nullable(p.PrevCursor),
+		},
+		"links": map[string]interface{}{
+			"prev": nullable(p.PrevURL()),
+			"next": nullable(p.NextURL()),
+		},
+	})
+}
+
+func encodeCursor(c cursor) string {
+	switch v := c.Value.(type) {
+	case time.Time:
+		c.Value, c.Time = v.Format(time.RFC3339Nano), true
+	case *time.Time:
+		if v != nil {
+			c.Value, c.Time = v.Format(time.RFC3339Nano), true
+		}
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor keeps integer keys exact instead of going through float64
+// and turns times back into time.Time
+func decodeCursor(encoded string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, fmt.Errorf("database: invalid cursor")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("database: invalid cursor")
+	}
+	if c.Time {
+		s, _ := c.Value.(string)
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return c, fmt.Errorf("database: invalid cursor")
+		}
+		c.Value = t
+	}
+	if n, ok := c.Value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			c.Value = i
+		} else if f, err := n.Float64(); err == nil {
+			c.Value = f
+		}
+	}
+	return c, nil
+}
+
+// cursorValue reads the cursor column from a struct or map item
+func cursorValue(item reflect.Value, column string) interface{} {
+	for item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface {
+		item = item.Elem()
+	}
+	column = column[strings.LastIndex(column, ".")+1:]
+	if item.Kind() == reflect.Map {
+		return item.MapIndex(reflect.ValueOf(column)).Interface()
+	}
+	if idx, ok := columnIndex(item.Type())[column]; ok {
+		return item.FieldByIndex(idx).Interface()
+	}
+	return nil
+}
+
+func reverse(slice reflect.Value) {
+	swap := reflect.Swapper(slice.Interface())
+	for i, j := 0, slice.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
+
+func pageURL(path string, query url.Values, key, value string) string {
+	values := url.Values{}
+	for k, v := range query {
+		values[k] = v
+	}
+	values.Set(key, value)
+	return path + "?" + values.Encode()
+}
+
+// nullable turns "" into a JSON null
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,507 @@
+// pkg/database/query.go
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// identifierRegex accepts column and table names, optionally qualified
+// ("posts.user_id") and "*" or "posts.*" for selects
+var identifierRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*\.)?([A-Za-z_][A-Za-z0-9_]*|\*)$`)
+
+// operators allowed in Where clauses
+var operators = map[string]bool{
+	"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true,
+	"LIKE": true, "NOT LIKE": true,
+}
+
+// errNoTable is returned by queries started with ORM.With that neither scan
+// into a model nor name a table
+var errNoTable = errors.New("database: query has no table, start it with Table or Model")
+
+type whereClause struct {
+	boolean  string // AND or OR
+	column   string
+	operator string
+	value    interface{}
+	values   []interface{}
+	kind     string // basic, in, not_in, null, not_null, group
+	nested   []whereClause
+}
+
+type joinClause struct {
+	kind     string // INNER or LEFT
+	table    string
+	first    string
+	operator string
+	second   string
+}
+
+// Query is a fluent SELECT/UPDATE/DELETE builder started by ORM.Table:
+//
+//	var posts []models.Post
+//	err := orm.Table("posts").
+//		Where("user_id", "=", 1).
+//		OrWhere("featured", "=", true).
+//		OrderBy("created_at", "desc").
+//		Limit(10).
+//		Get(&posts)
+//
+// Values are always sent as bind arguments. Invalid column names or
+// operators are reported by the method that runs the query.
+type Query struct {
+	orm     *ORM
+	table   string
+	columns []string
+	joins   []joinClause
+	wheres  []whereClause
+	orders  []string
+	limit   int
+	offset  int
+	with    []string
+	err     error
+
+	model         reflect.Type // set for model queries, see ORM.Model
+	removedScopes map[string]bool
+	noScopes      bool
+	onlyTrashed   bool
+}
+
+// Table starts a query on a table
+func (o *ORM) Table(table string) *Query {
+	q := &Query{orm: o, table: table, limit: -1, offset: -1}
+	q.check(table)
+	return q
+}
+
+// check records an error for an unsafe identifier
+func (q *Query) check(identifiers ...string) {
+	for _, ident := range identifiers {
+		if q.err == nil && !identifierRegex.MatchString(ident) {
+			q.err = fmt.Errorf("database: invalid identifier %q", ident)
+		}
+	}
+}
+
+// Select sets the selected columns, all by default
+func (q *Query) Select(columns ...string) *Query {
+	q.check(columns...)
+	q.columns = columns
+	return q
+}
+
+// Where adds an AND condition
+func (q *Query) Where(column, operator string, value interface{}) *Query {
+	return q.addWhere("AND", column, operator, value)
+}
+
+// OrWhere adds an OR condition
+func (q *Query) OrWhere(column, operator string, value interface{}) *Query {
+	return q.addWhere("OR", column, operator, value)
+}
+
+func (q *Query) addWhere(boolean, column, operator string, value interface{}) *Query {
+	q.check(column)
+	op := strings.ToUpper(strings.TrimSpace(operator))
+	if !operators[op] && q.err == nil {
+		q.err = fmt.Errorf("database: invalid operator %q", operator)
+	}
+
+	// Comparing with nil only makes sense as IS NULL
+	if value == nil && (op == "=" || op == "!=" || op == "<>") {
+		kind := "null"
+		if op != "=" {
+			kind = "not_null"
+		}
+		q.wheres = append(q.wheres, whereClause{boolean: boolean, column: column, kind: kind})
+		return q
+	}
+
+	q.wheres = append(q.wheres, whereClause{boolean: boolean, column: column, operator: op, value: value, kind: "basic"})
+	return q
+}
+
+// WhereIn adds "column IN (...)"; values is a slice of any type
+func (q *Query) WhereIn(column string, values interface{}) *Query {
+	return q.addWhereIn("in", column, values)
+}
+
+// WhereNotIn adds "column NOT IN (...)"
+func (q *Query) WhereNotIn(column string, values interface{}) *Query {
+	return q.addWhereIn("not_in", column, values)
+}
+
+func (q *Query) addWhereIn(kind, column string, values interface{}) *Query {
+	q.check(column)
+	list, err := toSlice(values)
+	if err != nil && q.err == nil {
+		q.err = err
+	}
+	q.wheres = append(q.wheres, whereClause{boolean: "AND", column: column, values: list, kind: kind})
+	return q
+}
+
+// WhereNull adds "column IS NULL"
+func (q *Query) WhereNull(column string) *Query {
+	q.check(column)
+	q.wheres = append(q.wheres, whereClause{boolean: "AND", column: column, kind: "null"})
+	return q
+}
+
+// WhereNotNull adds "column IS NOT NULL"
+func (q *Query) WhereNotNull(column string) *Query {
+	q.check(column)
+	q.wheres = append(q.wheres, whereClause{boolean: "AND", column: column, kind: "not_null"})
+	return q
+}
+
+// Join adds an INNER JOIN: Join("users", "users.id", "=", "posts.user_id")
+func (q *Query) Join(table, first, operator, second string) *Query {
+	return q.addJoin("INNER", table, first, operator, second)
+}
+
+// LeftJoin adds a LEFT JOIN
+func (q *Query) LeftJoin(table, first, operator, second string) *Query {
+	return q.addJoin("LEFT", table, first, operator, second)
+}
+
+func (q *Query) addJoin(kind, table, first, operator, second string) *Query {
+	q.check(table, first, second)
+	switch operator {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+	default:
+		if q.err == nil {
+			q.err = fmt.Errorf("database: invalid join operator %q", operator)
+		}
+	}
+	q.joins = append(q.joins, joinClause{kind: kind, table: table, first: first, operator: operator, second: second})
+	return q
+}
+
+// OrderBy adds a sort column, direction is asc or desc
+func (q *Query) OrderBy(column, direction string) *Query {
+	q.check(column)
+	dir := strings.ToUpper(direction)
+	if dir != "ASC" && dir != "DESC" {
+		if q.err == nil {
+			q.err = fmt.Errorf("database: invalid order direction %q", direction)
+		}
+		return q
+	}
+	q.orders = append(q.orders, quoteColumn(q.orm.dialect, column)+" "+dir)
+	return q
+}
+
+// Limit caps the number of rows
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Offset skips rows
+func (q *Query) Offset(n int) *Query {
+	q.offset = n
+	return q
+}
+
+// Get runs the query into dest, a pointer to a slice of structs, struct
+// pointers or map[string]interface{}
+func (q *Query) Get(dest interface{}) error {
+	q.modelFrom(dest)
+	query, args, err := q.toSelect(q.selectColumns())
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.orm.reads().Query(query, args...)
+	if err != nil {
+		return wrapQueryError(err, query)
+	}
+	defer rows.Close()
+	if err := scanAll(rows, dest); err != nil {
+		return err
+	}
+	// Free the connection before the relation queries run
+	rows.Close()
+	return q.orm.eagerLoad(dest, q.with)
+}
+
+// First reads the first row into dest and returns sql.ErrNoRows when there
+// is none
+func (q *Query) First(dest interface{}) error {
+	q.modelFrom(dest)
+	limited := *q
+	limited.limit = 1
+
+	query, args, err := limited.toSelect(q.selectColumns())
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.orm.reads().Query(query, args...)
+	if err != nil {
+		return wrapQueryError(err, query)
+	}
+	defer rows.Close()
+	if err := scanOne(rows, dest); err != nil {
+		return err
+	}
+	// Free the connection before the relation queries run
+	rows.Close()
+	return q.orm.eagerLoad(dest, q.with)
+}
+
+// Count returns the number of matching rows
+func (q *Query) Count() (int64, error) {
+	counted := *q
+	counted.orders = nil
+	counted.limit, counted.offset = -1, -1
+
+	query, args, err := counted.toSelect("COUNT(*)")
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := q.orm.reads().QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, wrapQueryError(err, query)
+	}
+	return count, nil
+}
+
+// Exists reports whether any row matches
+func (q *Query) Exists() (bool, error) {
+	probe := *q
+	probe.orders = nil
+	probe.limit, probe.offset = 1, -1
+
+	query, args, err := probe.toSelect("1")
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := q.orm.reads().Query(query, args...)
+	if err != nil {
+		return false, wrapQueryError(err, query)
+	}
+	defer rows.Close()
+	return rows.Next(), rows.Err()
+}
+
+// Pluck reads a single column into dest, a pointer to a slice
+func (q *Query) Pluck(column string, dest interface{}) error {
+	q.check(column)
+	query, args, err := q.toSelect(quoteColumn(q.orm.dialect, column))
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.orm.reads().Query(query, args...)
+	if err != nil {
+		return wrapQueryError(err, query)
+	}
+	defer rows.Close()
+	return scanAll(rows, dest)
+}
+
+// Update sets columns on the matching rows and returns the affected count
+func (q *Query) Update(values map[string]interface{}) (int64, error) {
+	if len(values) == 0 {
+		return 0, errors.New("database: update without values")
+	}
+
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		q.check(column)
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	if err := q.writable(); err != nil {
+		return 0, err
+	}
+
+	b := &binder{dialect: q.orm.dialect}
+	sets := make([]string, len(columns))
+	for i, column := range columns {
+		sets[i] = quoteColumn(q.orm.dialect, column) + " = " + b.bind(values[column])
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s", quoteColumn(q.orm.dialect, q.table), strings.Join(sets, ", "))
+	if where := q.scoped().compileWheres(b); where != "" {
+		query += " WHERE " + where
+	}
+	return q.exec(query, b.args)
+}
+
+// Delete removes the matching rows and returns the affected count. Rows of
+// soft deleting models are only marked as deleted.
+func (q *Query) Delete() (int64, error) {
+	if q.softDeleting() {
+		return q.Update(map[string]interface{}{deletedAtColumn: time.Now()})
+	}
+	return q.ForceDelete()
+}
+
+// ForceDelete removes the matching rows even for soft deleting models
+func (q *Query) ForceDelete() (int64, error) {
+	if err := q.writable(); err != nil {
+		return 0, err
+	}
+
+	b := &binder{dialect: q.orm.dialect}
+	query := "DELETE FROM " + quoteColumn(q.orm.dialect, q.table)
+	if where := q.scoped().compileWheres(b); where != "" {
+		query += " WHERE " + where
+	}
+	return q.exec(query, b.args)
+}
+
+// writable rejects clauses UPDATE and DELETE cannot express portably
+func (q *Query) writable() error {
+	if q.err != nil {
+		return q.err
+	}
+	if q.table == "" {
+		return errNoTable
+	}
+	if len(q.joins) > 0 || q.limit >= 0 || q.offset >= 0 || len(q.orders) > 0 {
+		return errors.New("database: update and delete do not support joins, order, limit or offset")
+	}
+	return nil
+}
+
+func (q *Query) exec(query string, args []interface{}) (int64, error) {
+	result, err := q.orm.db.Exec(query, args...)
+	if err != nil {
+		return 0, wrapQueryError(err, query)
+	}
+	return result.RowsAffected()
+}
+
+// ToSQL returns the SELECT statement and its arguments, for debugging
+func (q *Query) ToSQL() (string, []interface{}, error) {
+	return q.toSelect(q.selectColumns())
+}
+
+func (q *Query) selectColumns() string {
+	if len(q.columns) == 0 {
+		return "*"
+	}
+	quoted := make([]string, len(q.columns))
+	for i, column := range q.columns {
+		quoted[i] = quoteColumn(q.orm.dialect, column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (q *Query) toSelect(columns string) (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	if q.table == "" {
+		return "", nil, errNoTable
+	}
+	q = q.scoped()
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	d := q.orm.dialect
+	b := &binder{dialect: d}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", columns, quoteColumn(d, q.table))
+	for _, j := range q.joins {
+		fmt.Fprintf(&sb, " %s JOIN %s ON %s %s %s", j.kind, quoteColumn(d, j.table),
+			quoteColumn(d, j.first), j.operator, quoteColumn(d, j.second))
+	}
+	if where := q.compileWheres(b); where != "" {
+		sb.WriteString(" WHERE " + where)
+	}
+
+	limit := d.LimitOffset(q.limit, q.offset)
+	orders := q.orders
+	if len(orders) == 0 && limit != "" && d.Name() == "sqlserver" {
+		// OFFSET ... FETCH requires an ORDER BY
+		orders = []string{"(SELECT NULL)"}
+	}
+	if len(orders) > 0 {
+		sb.WriteString(" ORDER BY " + strings.Join(orders, ", "))
+	}
+	if limit != "" {
+		sb.WriteString(" " + limit)
+	}
+
+	return sb.String(), b.args, nil
+}
+
+func (q *Query) compileWheres(b *binder) string {
+	var sb strings.Builder
+	for i, w := range q.wheres {
+		if i > 0 {
+			sb.WriteString(" " + w.boolean + " ")
+		}
+		column := quoteColumn(b.dialect, w.column)
+
+		switch w.kind {
+		case "group":
+			sb.WriteString("(" + (&Query{wheres: w.nested}).compileWheres(b) + ")")
+		case "basic":
+			sb.WriteString(column + " " + w.operator + " " + b.bind(w.value))
+		case "null":
+			sb.WriteString(column + " IS NULL")
+		case "not_null":
+			sb.WriteString(column + " IS NOT NULL")
+		case "in", "not_in":
+			// An empty list matches nothing, or everything when negated
+			if len(w.values) == 0 {
+				if w.kind == "in" {
+					sb.WriteString("1 = 0")
+				} else {
+					sb.WriteString("1 = 1")
+				}
+				continue
+			}
+			placeholders := make([]string, len(w.values))
+			for i, v := range w.values {
+				placeholders[i] = b.bind(v)
+			}
+			op := "IN"
+			if w.kind == "not_in" {
+				op = "NOT IN"
+			}
+			sb.WriteString(column + " " + op + " (" + strings.Join(placeholders, ", ") + ")")
+		}
+	}
+	return sb.String()
+}
+
+// binder collects arguments and hands out the dialect's placeholders
+type binder struct {
+	dialect Dialect
+	args    []interface{}
+}
+
+func (b *binder) bind(value interface{}) string {
+	b.args = append(b.args, value)
+	return b.dialect.Placeholder(len(b.args))
+}
+
+func toSlice(values interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("database: WhereIn expects a slice, got %T", values)
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, nil
+}
@@ -0,0 +1,519 @@
+// pkg/database/relations.go
+package database
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// whereInChunk bounds the keys of one eager loading IN query; SQL Server
+// takes at most 2100 parameters
+const whereInChunk = 1000
+
+// Relation kinds
+const (
+	RelHasOne     = "has_one"
+	RelHasMany    = "has_many"
+	RelBelongsTo  = "belongs_to"
+	RelManyToMany = "many_to_many"
+)
+
+// Relation describes how a model field is loaded from another table. It is
+// declared with a `rel` tag:
+//
+//	type User struct {
+//		ID      int      `db:"id"`
+//		Profile *Profile `rel:"has_one"`
+//		Posts   []Post   `rel:"has_many,foreign_key=user_id"`
+//	}
+//
+//	type Post struct {
+//		ID     int    `db:"id"`
+//		UserID int    `db:"user_id"`
+//		Author *User  `rel:"belongs_to,foreign_key=user_id"`
+//		Tags   []Tag  `rel:"many_to_many,pivot=post_tag"`
+//	}
+//
+// or by a Relations method for models that prefer code over tags. Keys left
+// empty fall back to the conventions: "<singular parent table>_id" for
+// foreign keys and the primary key, "id" unless the model is a KeyedModel,
+// for local and owner keys.
+type Relation struct {
+	Kind       string
+	ForeignKey string // has_one/has_many: column on the related table; belongs_to: column on this model
+	LocalKey   string // has_one/has_many/many_to_many: key on this model; belongs_to: key on the related model
+	Pivot      string // many_to_many: the pivot table
+	PivotKey   string // many_to_many: pivot column pointing at this model
+	RelatedKey string // many_to_many: pivot column pointing at the related model
+}
+
+// RelationDefiner is implemented by models that declare relations in code,
+// keyed by field name
+type RelationDefiner interface {
+	Relations() map[string]Relation
+}
+
+func HasOne(foreignKey, localKey string) Relation {
+	return Relation{Kind: RelHasOne, ForeignKey: foreignKey, LocalKey: localKey}
+}
+
+func HasMany(foreignKey, localKey string) Relation {
+	return Relation{Kind: RelHasMany, ForeignKey: foreignKey, LocalKey: localKey}
+}
+
+func BelongsTo(foreignKey, ownerKey string) Relation {
+	return Relation{Kind: RelBelongsTo, ForeignKey: foreignKey, LocalKey: ownerKey}
+}
+
+func ManyToMany(pivot, pivotKey, relatedKey string) Relation {
+	return Relation{Kind: RelManyToMany, Pivot: pivot, PivotKey: pivotKey, RelatedKey: relatedKey}
+}
+
+// With starts a query that eager loads relations, nested with dots:
+//
+//	var users []models.User
+//	err := orm.With("Posts.Comments", "Profile").Find(&users)
+//
+// Each relation costs one batched IN query whatever the number of parents.
+// The table is the one of the model Find, Get or First scan into; queries
+// that scan no model, such as Count, need Table or Model instead.
+func (o *ORM) With(relations ...string) *Query {
+	return &Query{orm: o, limit: -1, offset: -1, with: relations}
+}
+
+// With adds relations to eager load after the query runs
+func (q *Query) With(relations ...string) *Query {
+	q.with = append(q.with, relations...)
+	return q
+}
+
+// Find runs the query into dest, a pointer to a model or a slice of models.
+// The table defaults to the model's TableName.
+func (q *Query) Find(dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("database: Find expects a pointer, got %T", dest)
+	}
+
+	if q.table == "" {
+		if _, ok := modelOf(rv.Elem().Type()); !ok {
+			return fmt.Errorf("database: %T does not implement Model", dest)
+		}
+	}
+
+	if rv.Elem().Kind() == reflect.Slice {
+		return q.Get(dest)
+	}
+	return q.First(dest)
+}
+
+// StrictLazyLoading makes prevented Load calls panic instead of logging;
+// the application turns it on in development
+var StrictLazyLoading bool
+
+// PreventLazyLoading reports Load calls, to catch N+1 queries where eager
+// loading with With was intended. Load panics when StrictLazyLoading is set
+// and otherwise logs the violation, then loads anyway.
+func (o *ORM) PreventLazyLoading(prevent bool) *ORM {
+	o.preventLazy = prevent
+	return o
+}
+
+// Load loads relations on models that were already fetched, a pointer to a
+// model or a slice of models
+func (o *ORM) Load(dest interface{}, relations ...string) error {
+	if o.preventLazy {
+		message := fmt.Sprintf("database: lazy loading %s on %T is prevented, eager load it with With", strings.Join(relations, ", "), dest)
+		if StrictLazyLoading {
+			panic(message)
+		}
+		log.Print(message)
+	}
+	return o.eagerLoad(dest, relations)
+}
+
+// eagerLoad loads relations onto the models in dest
+func (o *ORM) eagerLoad(dest interface{}, relations []string) error {
+	if len(relations) == 0 {
+		return nil
+	}
+	parents := structValues(reflect.ValueOf(dest))
+	if len(parents) == 0 {
+		return nil
+	}
+	return o.loadTree(parents, relationTree(relations))
+}
+
+// relationTree groups "Posts.Comments" and "Posts.Tags" under "Posts"
+func relationTree(relations []string) map[string][]string {
+	tree := make(map[string][]string)
+	for _, path := range relations {
+		name, rest, nested := strings.Cut(path, ".")
+		if _, ok := tree[name]; !ok {
+			tree[name] = nil
+		}
+		if nested {
+			tree[name] = append(tree[name], rest)
+		}
+	}
+	return tree
+}
+
+func (o *ORM) loadTree(parents []reflect.Value, tree map[string][]string) error {
+	// Sorted so the queries run in a stable order
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := o.loadRelation(parents, name, tree[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *ORM) loadRelation(parents []reflect.Value, name string, nested []string) error {
+	parentType := parents[0].Type()
+	sf, ok := parentType.FieldByName(name)
+	if !ok {
+		return fmt.Errorf("database: %s has no relation %s", parentType, name)
+	}
+	rel, err := relationOf(parentType, sf)
+	if err != nil {
+		return err
+	}
+
+	relatedType := indirect(sf.Type)
+	if relatedType.Kind() == reflect.Slice {
+		relatedType = indirect(relatedType.Elem())
+	}
+	related, ok := modelOf(relatedType)
+	if !ok {
+		return fmt.Errorf("database: relation %s.%s: %s does not implement Model", parentType, name, relatedType)
+	}
+	parentModel, _ := modelOf(parentType)
+
+	rel = withDefaults(rel, sf, parentModel, related)
+
+	// Keys on the parents that select the related rows
+	parentKey := rel.LocalKey
+	if rel.Kind == RelBelongsTo {
+		parentKey = rel.ForeignKey
+	}
+	keys := collectKeys(parents, parentKey)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// pivotMap maps a parent key to related keys for many_to_many
+	var pivotMap map[string][]string
+	relatedColumn := rel.ForeignKey
+	switch rel.Kind {
+	case RelBelongsTo:
+		relatedColumn = rel.LocalKey
+	case RelManyToMany:
+		pivotMap, keys, err = o.loadPivot(rel, keys)
+		if err != nil {
+			return err
+		}
+		relatedColumn = primaryKey(related)
+		if len(keys) == 0 {
+			return nil
+		}
+	}
+
+	rows := reflect.New(reflect.SliceOf(reflect.PointerTo(relatedType)))
+	err = getInChunks(func() *Query { return o.Model(related) }, relatedColumn, keys, rows)
+	if err != nil {
+		return fmt.Errorf("database: loading %s.%s: %v", parentType, name, err)
+	}
+
+	children := make([]reflect.Value, rows.Elem().Len())
+	for i := range children {
+		children[i] = rows.Elem().Index(i).Elem()
+	}
+	if len(nested) > 0 && len(children) > 0 {
+		if err := o.loadTree(children, relationTree(nested)); err != nil {
+			return err
+		}
+	}
+
+	// Group the children by the key that links them to a parent
+	byKey := make(map[string][]reflect.Value)
+	relatedIndex := columnIndex(relatedType)[relatedColumn]
+	for _, child := range children {
+		k := keyString(child.FieldByIndex(relatedIndex))
+		byKey[k] = append(byKey[k], child)
+	}
+
+	parentIndex := columnIndex(parentType)[parentKey]
+	for _, parent := range parents {
+		k := keyString(parent.FieldByIndex(parentIndex))
+		matches := byKey[k]
+		if rel.Kind == RelManyToMany {
+			matches = nil
+			for _, relatedKey := range pivotMap[k] {
+				matches = append(matches, byKey[relatedKey]...)
+			}
+		}
+		assign(parent.FieldByIndex(sf.Index), matches)
+	}
+	return nil
+}
+
+// loadPivot reads the pivot rows of the parents, returning the related keys
+// per parent key and all related keys
+func (o *ORM) loadPivot(rel Relation, keys []interface{}) (map[string][]string, []interface{}, error) {
+	var pivots []map[string]interface{}
+	err := getInChunks(func() *Query {
+		return o.Table(rel.Pivot).Select(rel.PivotKey, rel.RelatedKey)
+	}, rel.PivotKey, keys, reflect.ValueOf(&pivots))
+	if err != nil {
+		return nil, nil, fmt.Errorf("database: loading pivot %s: %v", rel.Pivot, err)
+	}
+
+	pivotMap := make(map[string][]string)
+	seen := make(map[string]bool)
+	var relatedKeys []interface{}
+	for _, row := range pivots {
+		parent := keyString(reflect.ValueOf(row[rel.PivotKey]))
+		related := keyString(reflect.ValueOf(row[rel.RelatedKey]))
+		pivotMap[parent] = append(pivotMap[parent], related)
+		if !seen[related] {
+			seen[related] = true
+			relatedKeys = append(relatedKeys, row[rel.RelatedKey])
+		}
+	}
+	return pivotMap, relatedKeys, nil
+}
+
+// getInChunks runs "column IN (keys)" queries of at most whereInChunk keys
+// and appends the rows to dest, a pointer to a slice
+func getInChunks(query func() *Query, column string, keys []interface{}, dest reflect.Value) error {
+	for start := 0; start < len(keys); start += whereInChunk {
+		end := min(start+whereInChunk, len(keys))
+		chunk := reflect.New(dest.Elem().Type())
+		if err := query().WhereIn(column, keys[start:end]).Get(chunk.Interface()); err != nil {
+			return err
+		}
+		dest.Elem().Set(reflect.AppendSlice(dest.Elem(), chunk.Elem()))
+	}
+	return nil
+}
+
+// relationOf reads the relation of a field from its tag or the model's
+// Relations method
+func relationOf(parentType reflect.Type, sf reflect.StructField) (Relation, error) {
+	if tag := sf.Tag.Get("rel"); tag != "" {
+		return parseRelationTag(tag)
+	}
+	if definer, ok := reflect.New(parentType).Interface().(RelationDefiner); ok {
+		if rel, ok := definer.Relations()[sf.Name]; ok {
+			return rel, nil
+		}
+	}
+	return Relation{}, fmt.Errorf("database: %s.%s is not a relation", parentType, sf.Name)
+}
+
+func parseRelationTag(tag string) (Relation, error) {
+	parts := strings.Split(tag, ",")
+	rel := Relation{Kind: strings.TrimSpace(parts[0])}
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "foreign_key":
+			rel.ForeignKey = value
+		case "local_key", "owner_key":
+			rel.LocalKey = value
+		case "pivot":
+			rel.Pivot = value
+		case "foreign_pivot_key":
+			rel.PivotKey = value
+		case "related_pivot_key":
+			rel.RelatedKey = value
+		default:
+			return rel, fmt.Errorf("database: unknown relation option %q", key)
+		}
+	}
+
+	switch rel.Kind {
+	case RelHasOne, RelHasMany, RelBelongsTo, RelManyToMany:
+		return rel, nil
+	}
+	return rel, fmt.Errorf("database: unknown relation kind %q", rel.Kind)
+}
+
+func withDefaults(rel Relation, sf reflect.StructField, parent, related Model) Relation {
+	if rel.LocalKey == "" {
+		rel.LocalKey = primaryKey(parent)
+		if rel.Kind == RelBelongsTo {
+			rel.LocalKey = primaryKey(related)
+		}
+	}
+	switch rel.Kind {
+	case RelHasOne, RelHasMany:
+		if rel.ForeignKey == "" {
+			rel.ForeignKey = singular(parent.TableName()) + "_id"
+		}
+	case RelBelongsTo:
+		if rel.ForeignKey == "" {
+			rel.ForeignKey = snakeCase(sf.Name) + "_id"
+		}
+	case RelManyToMany:
+		if rel.Pivot == "" {
+			tables := []string{singular(parent.TableName()), singular(related.TableName())}
+			sort.Strings(tables)
+			rel.Pivot = strings.Join(tables, "_")
+		}
+		if rel.PivotKey == "" {
+			rel.PivotKey = singular(parent.TableName()) + "_id"
+		}
+		if rel.RelatedKey == "" {
+			rel.RelatedKey = singular(related.TableName()) + "_id"
+		}
+	}
+	return rel
+}
+
+// assign stores the loaded children in a relation field of type T, *T, []T
+// or []*T
+func assign(field reflect.Value, children []reflect.Value) {
+	t := field.Type()
+	if t.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(t, 0, len(children))
+		for _, child := range children {
+			slice = reflect.Append(slice, asType(child, t.Elem()))
+		}
+		field.Set(slice)
+		return
+	}
+	if len(children) == 0 {
+		field.Set(reflect.Zero(t))
+		return
+	}
+	field.Set(asType(children[0], t))
+}
+
+func asType(child reflect.Value, t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Ptr {
+		return child.Addr()
+	}
+	return child
+}
+
+// structValues returns the addressable structs behind a pointer to a model
+// or to a slice of models
+func structValues(rv reflect.Value) []reflect.Value {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{rv}
+	case reflect.Slice:
+		values := make([]reflect.Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, structValues(rv.Index(i).Addr())...)
+		}
+		return values
+	}
+	return nil
+}
+
+// collectKeys returns the distinct non-zero values of a column
+func collectKeys(values []reflect.Value, column string) []interface{} {
+	idx, ok := columnIndex(values[0].Type())[column]
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var keys []interface{}
+	for _, v := range values {
+		fv := v.FieldByIndex(idx)
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr || fv.IsZero() {
+			continue
+		}
+		k := keyString(fv)
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, fv.Interface())
+		}
+	}
+	return keys
+}
+
+// keyString normalizes keys so an int64 from the driver matches an int
+// field
+func keyString(v reflect.Value) string {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// modelOf returns a zero model for a struct, pointer or slice type
+func modelOf(t reflect.Type) (Model, bool) {
+	t = indirect(t)
+	if t.Kind() == reflect.Slice {
+		t = indirect(t.Elem())
+	}
+	model, ok := reflect.New(t).Interface().(Model)
+	return model, ok
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// singular turns a table name into the singular used in key names
+func singular(table string) string {
+	switch {
+	case strings.HasSuffix(table, "ies"):
+		return strings.TrimSuffix(table, "ies") + "y"
+	case strings.HasSuffix(table, "sses"), strings.HasSuffix(table, "xes"):
+		return table[:len(table)-2]
+	case strings.HasSuffix(table, "s") && !strings.HasSuffix(table, "ss"):
+		return strings.TrimSuffix(table, "s")
+	}
+	return table
+}
+
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && !(name[i-1] >= 'A' && name[i-1] <= 'Z') {
+				sb.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type testAuthor struct {
+	ID    int        `db:"id"`
+	Name  string     `db:"name"`
+	Posts []testPost `rel:"has_many,foreign_key=author_id"`
+}
+
+func (testAuthor) TableName() string { return "authors" }
+
+type testPost struct {
+	ID       int    `db:"id"`
+	AuthorID int    `db:"author_id"`
+	Title    string `db:"title"`
+}
+
+func (testPost) TableName() string { return "posts" }
+
+func newSQLiteORM(t *testing.T) *ORM {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, statement := range []string{
+		"CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, author_id INTEGER, title TEXT)",
+		"INSERT INTO authors (id, name) VALUES (1, 'Ada'), (2, 'Grace')",
+		"INSERT INTO posts (author_id, title) VALUES (1, 'Engines'), (1, 'Notes'), (2, 'Compilers')",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewORM(db).UseDialect(SQLiteDialect{})
+}
+
+func TestWithReadsTheModelTable(t *testing.T) {
+	orm := newSQLiteORM(t)
+
+	var authors []testAuthor
+	if err := orm.With("Posts").OrderBy("id", "asc").Get(&authors); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(authors) != 2 || len(authors[0].Posts) != 2 || len(authors[1].Posts) != 1 {
+		t.Fatalf("unexpected authors: %+v", authors)
+	}
+
+	var first testAuthor
+	if err := orm.With("Posts").Where("name", "=", "Grace").First(&first); err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	if first.ID != 2 || len(first.Posts) != 1 {
+		t.Fatalf("unexpected author: %+v", first)
+	}
+
+	page, err := orm.With("Posts").Paginate(1, 1, &authors)
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+	if page.Total != 2 {
+		t.Fatalf("Paginate counted %d authors", page.Total)
+	}
+}
+
+func TestQueryWithoutTableFails(t *testing.T) {
+	orm := newSQLiteORM(t)
+
+	if _, err := orm.With("Posts").Count(); !errors.Is(err, errNoTable) {
+		t.Errorf("Count: got %v, want errNoTable", err)
+	}
+	var rows []map[string]interface{}
+	if err := orm.With("Posts").Get(&rows); !errors.Is(err, errNoTable) {
+		t.Errorf("Get into maps: got %v, want errNoTable", err)
+	}
+	if _, err := orm.With().Delete(); !errors.Is(err, errNoTable) {
+		t.Errorf("Delete: got %v, want errNoTable", err)
+	}
+}
@@ -0,0 +1,174 @@
+// pkg/database/scan.go
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// field is a struct field mapped to a column through its db tag
+type field struct {
+	column string
+	index  []int
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+// modelFields lists the db tagged fields of a struct type. Embedded structs
+// without a db tag are flattened into the parent, so shared fields such as
+// timestamps can live in their own struct.
+func modelFields(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("db")
+			idx := append(append([]int(nil), index...), i)
+
+			if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Time{}) {
+				walk(f.Type, idx)
+				continue
+			}
+			if !f.IsExported() || tag == "" || tag == "-" {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			fields = append(fields, field{column: name, index: idx})
+		}
+	}
+	walk(t, nil)
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// columnIndex maps column names to field indexes
+func columnIndex(t reflect.Type) map[string][]int {
+	fields := modelFields(t)
+	index := make(map[string][]int, len(fields))
+	for _, f := range fields {
+		index[f.column] = f.index
+	}
+	return index
+}
+
+// scanAll reads every row into dest, a pointer to a slice of structs,
+// struct pointers or map[string]interface{}
+func scanAll(rows *sql.Rows, dest interface{}) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("database: expected a pointer to a slice, got %T", dest)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	result := reflect.MakeSlice(slice.Type(), 0, 0)
+	for rows.Next() {
+		elem := reflect.New(elemType).Elem()
+		target := elem
+		if elemType.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elemType.Elem()))
+			target = elem.Elem()
+		}
+		if err := scanInto(rows, columns, target); err != nil {
+			return err
+		}
+		result = reflect.Append(result, elem)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	slice.Set(result)
+	return nil
+}
+
+// scanOne reads the first row into dest, a pointer to a struct or map
+func scanOne(rows *sql.Rows, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("database: expected a pointer, got %T", dest)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	return scanInto(rows, columns, rv.Elem())
+}
+
+func scanInto(rows *sql.Rows, columns []string, target reflect.Value) error {
+	switch target.Kind() {
+	case reflect.Map:
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for i, column := range columns {
+			value := values[i]
+			// Most drivers return text columns as []byte
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			target.SetMapIndex(reflect.ValueOf(column), reflect.ValueOf(&value).Elem())
+		}
+		return nil
+
+	case reflect.Struct:
+		if isScalarStruct(target) {
+			break
+		}
+		index := columnIndex(target.Type())
+		pointers := make([]interface{}, len(columns))
+		for i, column := range columns {
+			if idx, ok := index[column]; ok {
+				pointers[i] = target.FieldByIndex(idx).Addr().Interface()
+			} else {
+				// Columns the struct does not map are read and dropped
+				pointers[i] = new(interface{})
+			}
+		}
+		return rows.Scan(pointers...)
+	}
+
+	// A single column into a scalar, as used by Pluck
+	return rows.Scan(target.Addr().Interface())
+}
+
+// isScalarStruct reports struct values that hold a single column, such as
+// time.Time and sql.NullString
+func isScalarStruct(v reflect.Value) bool {
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+	_, ok := v.Addr().Interface().(sql.Scanner)
+	return ok
+}
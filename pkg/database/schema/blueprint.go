@@ -0,0 +1,365 @@
+// pkg/database/schema/blueprint.go
+package schema
+
+import (
+	"strings"
+)
+
+// Blueprint collects the columns, indexes and other changes of one table.
+// It is passed to the callbacks of Create and Table:
+//
+//	schema.Create("posts", func(t *schema.Blueprint) {
+//		t.ID()
+//		t.String("title", 255)
+//		t.ForeignID("user_id").Constrained().CascadeOnDelete()
+//		t.Timestamps()
+//		t.SoftDeletes()
+//	})
+type Blueprint struct {
+	table    string
+	columns  []*ColumnDefinition
+	commands []command
+}
+
+// command is a change other than adding a column
+type command struct {
+	kind    string // primary, unique, index, foreign, drop_column, rename_column, drop_index, drop_foreign
+	name    string
+	columns []string
+	to      string // rename_column
+	foreign *ForeignKeyDefinition
+}
+
+// Column types; the grammar maps them to each dialect
+const (
+	typeBigIncrements = "big_increments"
+	typeIncrements    = "increments"
+	typeString        = "string"
+	typeChar          = "char"
+	typeText          = "text"
+	typeLongText      = "long_text"
+	typeInteger       = "integer"
+	typeBigInteger    = "big_integer"
+	typeSmallInteger  = "small_integer"
+	typeTinyInteger   = "tiny_integer"
+	typeBoolean       = "boolean"
+	typeDecimal       = "decimal"
+	typeFloat         = "float"
+	typeDouble        = "double"
+	typeDate          = "date"
+	typeDateTime      = "datetime"
+	typeTime          = "time"
+	typeTimestamp     = "timestamp"
+	typeJSON          = "json"
+	typeUUID          = "uuid"
+	typeBinary        = "binary"
+)
+
+// ColumnDefinition is a column being added, with its modifiers
+type ColumnDefinition struct {
+	name       string
+	kind       string
+	length     int
+	precision  int
+	scale      int
+	unsigned   bool
+	nullable   bool
+	hasDefault bool
+	def        interface{}
+	blueprint  *Blueprint
+}
+
+// Expression is raw SQL used as a default value, e.g. Raw("CURRENT_TIMESTAMP")
+type Expression string
+
+// Raw marks a default value as SQL instead of a literal
+func Raw(sql string) Expression {
+	return Expression(sql)
+}
+
+func (b *Blueprint) addColumn(kind, name string) *ColumnDefinition {
+	c := &ColumnDefinition{name: name, kind: kind, blueprint: b}
+	b.columns = append(b.columns, c)
+	return c
+}
+
+// ID adds an auto-incrementing big integer primary key named "id"
+func (b *Blueprint) ID() *ColumnDefinition {
+	return b.BigIncrements("id")
+}
+
+// BigIncrements adds an auto-incrementing big integer primary key
+func (b *Blueprint) BigIncrements(name string) *ColumnDefinition {
+	return b.addColumn(typeBigIncrements, name).Unsigned()
+}
+
+// Increments adds an auto-incrementing integer primary key
+func (b *Blueprint) Increments(name string) *ColumnDefinition {
+	return b.addColumn(typeIncrements, name).Unsigned()
+}
+
+// String adds a VARCHAR column, 255 long unless a length is given
+func (b *Blueprint) String(name string, length ...int) *ColumnDefinition {
+	c := b.addColumn(typeString, name)
+	c.length = 255
+	if len(length) > 0 {
+		c.length = length[0]
+	}
+	return c
+}
+
+// Char adds a fixed length CHAR column
+func (b *Blueprint) Char(name string, length int) *ColumnDefinition {
+	c := b.addColumn(typeChar, name)
+	c.length = length
+	return c
+}
+
+func (b *Blueprint) Text(name string) *ColumnDefinition     { return b.addColumn(typeText, name) }
+func (b *Blueprint) LongText(name string) *ColumnDefinition { return b.addColumn(typeLongText, name) }
+func (b *Blueprint) Integer(name string) *ColumnDefinition  { return b.addColumn(typeInteger, name) }
+func (b *Blueprint) BigInteger(name string) *ColumnDefinition {
+	return b.addColumn(typeBigInteger, name)
+}
+func (b *Blueprint) SmallInteger(name string) *ColumnDefinition {
+	return b.addColumn(typeSmallInteger, name)
+}
+func (b *Blueprint) TinyInteger(name string) *ColumnDefinition {
+	return b.addColumn(typeTinyInteger, name)
+}
+func (b *Blueprint) UnsignedBigInteger(name string) *ColumnDefinition {
+	return b.BigInteger(name).Unsigned()
+}
+func (b *Blueprint) Boolean(name string) *ColumnDefinition  { return b.addColumn(typeBoolean, name) }
+func (b *Blueprint) Float(name string) *ColumnDefinition    { return b.addColumn(typeFloat, name) }
+func (b *Blueprint) Double(name string) *ColumnDefinition   { return b.addColumn(typeDouble, name) }
+func (b *Blueprint) Date(name string) *ColumnDefinition     { return b.addColumn(typeDate, name) }
+func (b *Blueprint) DateTime(name string) *ColumnDefinition { return b.addColumn(typeDateTime, name) }
+func (b *Blueprint) Time(name string) *ColumnDefinition     { return b.addColumn(typeTime, name) }
+func (b *Blueprint) Timestamp(name string) *ColumnDefinition {
+	return b.addColumn(typeTimestamp, name)
+}
+func (b *Blueprint) JSON(name string) *ColumnDefinition   { return b.addColumn(typeJSON, name) }
+func (b *Blueprint) UUID(name string) *ColumnDefinition   { return b.addColumn(typeUUID, name) }
+func (b *Blueprint) Binary(name string) *ColumnDefinition { return b.addColumn(typeBinary, name) }
+
+// Decimal adds a fixed point column, e.g. Decimal("price", 10, 2)
+func (b *Blueprint) Decimal(name string, precision, scale int) *ColumnDefinition {
+	c := b.addColumn(typeDecimal, name)
+	c.precision, c.scale = precision, scale
+	return c
+}
+
+// Timestamps adds the nullable created_at and updated_at columns the ORM
+// fills in
+func (b *Blueprint) Timestamps() {
+	b.Timestamp("created_at").Nullable()
+	b.Timestamp("updated_at").Nullable()
+}
+
+// SoftDeletes adds the nullable deleted_at column of database.SoftDeletes
+func (b *Blueprint) SoftDeletes() *ColumnDefinition {
+	return b.Timestamp("deleted_at").Nullable()
+}
+
+// RememberToken adds a nullable remember_token column
+func (b *Blueprint) RememberToken() *ColumnDefinition {
+	return b.String("remember_token", 100).Nullable()
+}
+
+// Nullable allows NULL in the column
+func (c *ColumnDefinition) Nullable() *ColumnDefinition {
+	c.nullable = true
+	return c
+}
+
+// Unsigned makes an integer column unsigned where the database supports it
+func (c *ColumnDefinition) Unsigned() *ColumnDefinition {
+	c.unsigned = true
+	return c
+}
+
+// Default sets the default value; use Raw for SQL expressions
+func (c *ColumnDefinition) Default(value interface{}) *ColumnDefinition {
+	c.hasDefault = true
+	c.def = value
+	return c
+}
+
+// UseCurrent defaults a timestamp column to the current time
+func (c *ColumnDefinition) UseCurrent() *ColumnDefinition {
+	return c.Default(Raw("CURRENT_TIMESTAMP"))
+}
+
+// Primary makes the column (part of) the primary key
+func (c *ColumnDefinition) Primary() *ColumnDefinition {
+	c.blueprint.Primary(c.name)
+	return c
+}
+
+// Unique adds a unique index on the column
+func (c *ColumnDefinition) Unique() *ColumnDefinition {
+	c.blueprint.Unique(c.name)
+	return c
+}
+
+// Index adds an index on the column
+func (c *ColumnDefinition) Index() *ColumnDefinition {
+	c.blueprint.Index(c.name)
+	return c
+}
+
+// ForeignIDColumn is an unsigned big integer column meant to reference
+// another table's id
+type ForeignIDColumn struct {
+	*ColumnDefinition
+}
+
+// ForeignID adds an unsigned big integer column for a foreign key
+func (b *Blueprint) ForeignID(name string) *ForeignIDColumn {
+	return &ForeignIDColumn{b.UnsignedBigInteger(name)}
+}
+
+// Nullable allows NULL in the column
+func (c *ForeignIDColumn) Nullable() *ForeignIDColumn {
+	c.ColumnDefinition.Nullable()
+	return c
+}
+
+// Constrained adds the foreign key, to the given table or to the plural of
+// the column name without "_id" ("user_id" references users.id)
+func (c *ForeignIDColumn) Constrained(table ...string) *ForeignKeyDefinition {
+	on := plural(strings.TrimSuffix(c.name, "_id"))
+	if len(table) > 0 {
+		on = table[0]
+	}
+	return c.blueprint.Foreign(c.name).References("id").On(on)
+}
+
+// ForeignKeyDefinition is a foreign key constraint
+type ForeignKeyDefinition struct {
+	columns    []string
+	references []string
+	on         string
+	onDelete   string
+	onUpdate   string
+}
+
+// Foreign adds a foreign key on the columns; chain References and On
+func (b *Blueprint) Foreign(columns ...string) *ForeignKeyDefinition {
+	fk := &ForeignKeyDefinition{columns: columns}
+	b.commands = append(b.commands, command{
+		kind:    "foreign",
+		name:    b.indexName("foreign", columns),
+		columns: columns,
+		foreign: fk,
+	})
+	return fk
+}
+
+func (f *ForeignKeyDefinition) References(columns ...string) *ForeignKeyDefinition {
+	f.references = columns
+	return f
+}
+
+func (f *ForeignKeyDefinition) On(table string) *ForeignKeyDefinition {
+	f.on = table
+	return f
+}
+
+// OnDelete sets the action: CASCADE, SET NULL, RESTRICT or NO ACTION
+func (f *ForeignKeyDefinition) OnDelete(action string) *ForeignKeyDefinition {
+	f.onDelete = strings.ToUpper(action)
+	return f
+}
+
+// OnUpdate sets the action: CASCADE, SET NULL, RESTRICT or NO ACTION
+func (f *ForeignKeyDefinition) OnUpdate(action string) *ForeignKeyDefinition {
+	f.onUpdate = strings.ToUpper(action)
+	return f
+}
+
+func (f *ForeignKeyDefinition) CascadeOnDelete() *ForeignKeyDefinition { return f.OnDelete("CASCADE") }
+func (f *ForeignKeyDefinition) NullOnDelete() *ForeignKeyDefinition    { return f.OnDelete("SET NULL") }
+func (f *ForeignKeyDefinition) RestrictOnDelete() *ForeignKeyDefinition {
+	return f.OnDelete("RESTRICT")
+}
+func (f *ForeignKeyDefinition) CascadeOnUpdate() *ForeignKeyDefinition { return f.OnUpdate("CASCADE") }
+
+// Primary sets a (composite) primary key
+func (b *Blueprint) Primary(columns ...string) {
+	b.commands = append(b.commands, command{kind: "primary", name: b.indexName("primary", columns), columns: columns})
+}
+
+// Unique adds a unique index; the name defaults to table_columns_unique
+func (b *Blueprint) Unique(columns ...string) {
+	b.commands = append(b.commands, command{kind: "unique", name: b.indexName("unique", columns), columns: columns})
+}
+
+// Index adds an index; the name defaults to table_columns_index
+func (b *Blueprint) Index(columns ...string) {
+	b.commands = append(b.commands, command{kind: "index", name: b.indexName("index", columns), columns: columns})
+}
+
+// DropColumn removes columns
+func (b *Blueprint) DropColumn(columns ...string) {
+	b.commands = append(b.commands, command{kind: "drop_column", columns: columns})
+}
+
+// RenameColumn renames a column
+func (b *Blueprint) RenameColumn(from, to string) {
+	b.commands = append(b.commands, command{kind: "rename_column", columns: []string{from}, to: to})
+}
+
+// DropIndex drops an index by name, or by its columns when given several
+// names: DropIndex("posts_title_index") or DropIndex("title", "slug")
+func (b *Blueprint) DropIndex(index ...string) {
+	b.commands = append(b.commands, command{kind: "drop_index", name: b.dropName("index", index)})
+}
+
+// DropUnique drops a unique index by name or columns
+func (b *Blueprint) DropUnique(index ...string) {
+	b.commands = append(b.commands, command{kind: "drop_index", name: b.dropName("unique", index)})
+}
+
+// DropForeign drops a foreign key by name or columns
+func (b *Blueprint) DropForeign(index ...string) {
+	b.commands = append(b.commands, command{kind: "drop_foreign", name: b.dropName("foreign", index)})
+}
+
+// DropTimestamps removes created_at and updated_at
+func (b *Blueprint) DropTimestamps() {
+	b.DropColumn("created_at", "updated_at")
+}
+
+// DropSoftDeletes removes deleted_at
+func (b *Blueprint) DropSoftDeletes() {
+	b.DropColumn("deleted_at")
+}
+
+// indexName builds Laravel style names such as posts_user_id_foreign
+func (b *Blueprint) indexName(kind string, columns []string) string {
+	name := b.table + "_" + strings.Join(columns, "_") + "_" + kind
+	return strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToLower(name))
+}
+
+// dropName takes a single full name as is and builds one from columns
+// otherwise; a single column name is recognized by the missing table prefix
+func (b *Blueprint) dropName(kind string, index []string) string {
+	if len(index) == 1 && strings.HasPrefix(index[0], b.table+"_") {
+		return index[0]
+	}
+	return b.indexName(kind, index)
+}
+
+// plural is the small English pluralizer used for table names
+func plural(word string) string {
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	}
+	return word + "s"
+}
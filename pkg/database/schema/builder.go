@@ -0,0 +1,183 @@
+// pkg/database/schema/builder.go
+package schema
+
+import (
+	"fmt"
+	"mygola/pkg/database"
+	"sync"
+)
+
+// Builder runs schema changes on a connection, compiled for its dialect
+type Builder struct {
+	db      database.Executor
+	grammar grammar
+}
+
+// NewBuilder creates a schema builder; db is a *sql.DB or *sql.Tx
+//
+//	s := schema.NewBuilder(db, database.DialectFor("pgsql"))
+func NewBuilder(db database.Executor, dialect database.Dialect) *Builder {
+	return &Builder{db: db, grammar: grammar{d: dialect}}
+}
+
+// Create creates a table
+func (s *Builder) Create(table string, fn func(t *Blueprint)) error {
+	b := &Blueprint{table: table}
+	fn(b)
+	statements, err := s.grammar.compileCreate(b)
+	if err != nil {
+		return err
+	}
+	return s.run(statements...)
+}
+
+// Table changes an existing table: columns added in fn are appended, and
+// indexes, foreign keys and drops run in the order they were declared
+func (s *Builder) Table(table string, fn func(t *Blueprint)) error {
+	b := &Blueprint{table: table}
+	fn(b)
+	statements, err := s.grammar.compileAlter(b)
+	if err != nil {
+		return err
+	}
+	return s.run(statements...)
+}
+
+// Drop drops a table
+func (s *Builder) Drop(table string) error {
+	return s.run(s.grammar.compileDrop(table, false))
+}
+
+// DropIfExists drops a table when it exists
+func (s *Builder) DropIfExists(table string) error {
+	return s.run(s.grammar.compileDrop(table, true))
+}
+
+// Rename renames a table
+func (s *Builder) Rename(from, to string) error {
+	return s.run(s.grammar.compileRename(from, to))
+}
+
+// HasTable reports whether the table exists
+func (s *Builder) HasTable(table string) (bool, error) {
+	return s.count(s.grammar.compileHasTable(), table)
+}
+
+// HasColumn reports whether the table has the column
+func (s *Builder) HasColumn(table, column string) (bool, error) {
+	return s.count(s.grammar.compileHasColumn(), table, column)
+}
+
+// ToSQL returns the statements Create or Table would run, without running
+// them
+func (s *Builder) ToSQL(table string, create bool, fn func(t *Blueprint)) ([]string, error) {
+	b := &Blueprint{table: table}
+	fn(b)
+	if create {
+		return s.grammar.compileCreate(b)
+	}
+	return s.grammar.compileAlter(b)
+}
+
+func (s *Builder) run(statements ...string) error {
+	for _, statement := range statements {
+		if _, err := s.db.Exec(statement); err != nil {
+			return fmt.Errorf("schema: %v\nSQL: %s", err, statement)
+		}
+	}
+	return nil
+}
+
+func (s *Builder) count(query string, args ...interface{}) (bool, error) {
+	var n int64
+	if err := s.db.QueryRow(query, args...).Scan(&n); err != nil {
+		return false, fmt.Errorf("schema: %v\nSQL: %s", err, query)
+	}
+	return n > 0, nil
+}
+
+// The package functions run on the builder set with Use; the migration
+// runner points it at the connection of the migration being run.
+var (
+	current   *Builder
+	currentMu sync.RWMutex
+)
+
+// Use sets the builder of the package functions
+func Use(s *Builder) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = s
+}
+
+func builder() (*Builder, error) {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	if current == nil {
+		return nil, fmt.Errorf("schema: no connection, call schema.Use first")
+	}
+	return current, nil
+}
+
+// Create creates a table with the current builder
+func Create(table string, fn func(t *Blueprint)) error {
+	s, err := builder()
+	if err != nil {
+		return err
+	}
+	return s.Create(table, fn)
+}
+
+// Table changes a table with the current builder
+func Table(table string, fn func(t *Blueprint)) error {
+	s, err := builder()
+	if err != nil {
+		return err
+	}
+	return s.Table(table, fn)
+}
+
+// Drop drops a table with the current builder
+func Drop(table string) error {
+	s, err := builder()
+	if err != nil {
+		return err
+	}
+	return s.Drop(table)
+}
+
+// DropIfExists drops a table, if it exists, with the current builder
+func DropIfExists(table string) error {
+	s, err := builder()
+	if err != nil {
+		return err
+	}
+	return s.DropIfExists(table)
+}
+
+// Rename renames a table with the current builder
+func Rename(from, to string) error {
+	s, err := builder()
+	if err != nil {
+		return err
+	}
+	return s.Rename(from, to)
+}
+
+// HasTable reports whether a table exists on the current connection
+func HasTable(table string) (bool, error) {
+	s, err := builder()
+	if err != nil {
+		return false, err
+	}
+	return s.HasTable(table)
+}
+
+// HasColumn reports whether a table has a column on the current connection
+func HasColumn(table, column string) (bool, error) {
+	s, err := builder()
+	if err != nil {
+		return false, err
+	}
+	return s.HasColumn(table, column)
+}
@@ -0,0 +1,340 @@
+// pkg/database/schema/grammar.go
+package schema
+
+import (
+	"fmt"
+	"mygola/pkg/database"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// grammar compiles blueprints to the SQL of one dialect
+type grammar struct {
+	d database.Dialect
+}
+
+func (g grammar) name() string {
+	return g.d.Name()
+}
+
+func (g grammar) quote(ident string) string {
+	return g.d.Quote(ident)
+}
+
+func (g grammar) columnList(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = g.quote(c)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// compileCreate returns CREATE TABLE followed by its indexes. Primary and
+// foreign keys go inside CREATE TABLE, the only place SQLite accepts them.
+func (g grammar) compileCreate(b *Blueprint) ([]string, error) {
+	var defs []string
+	for _, c := range b.columns {
+		defs = append(defs, g.columnDefinition(c))
+	}
+	for _, cmd := range b.commands {
+		switch cmd.kind {
+		case "primary":
+			defs = append(defs, fmt.Sprintf("CONSTRAINT %s PRIMARY KEY (%s)", g.quote(cmd.name), g.columnList(cmd.columns)))
+		case "foreign":
+			fk, err := g.foreignKey(cmd)
+			if err != nil {
+				return nil, err
+			}
+			defs = append(defs, fk)
+		}
+	}
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)", g.quote(b.table), strings.Join(defs, ",\n\t"))}
+	for _, cmd := range b.commands {
+		switch cmd.kind {
+		case "primary", "foreign":
+			continue
+		}
+		sql, err := g.compileCommand(b, cmd)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, sql...)
+	}
+	return statements, nil
+}
+
+// compileAlter returns one statement per added column and command
+func (g grammar) compileAlter(b *Blueprint) ([]string, error) {
+	var statements []string
+	table := g.quote(b.table)
+	for _, c := range b.columns {
+		if c.kind == typeBigIncrements || c.kind == typeIncrements {
+			if g.name() == "sqlite" {
+				return nil, fmt.Errorf("schema: sqlite cannot add a primary key column to %s", b.table)
+			}
+		}
+		add := "ADD COLUMN"
+		if g.name() == "mysql" || g.name() == "sqlserver" {
+			add = "ADD"
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s %s %s", table, add, g.columnDefinition(c)))
+	}
+	for _, cmd := range b.commands {
+		sql, err := g.compileCommand(b, cmd)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, sql...)
+	}
+	return statements, nil
+}
+
+func (g grammar) compileCommand(b *Blueprint, cmd command) ([]string, error) {
+	table := g.quote(b.table)
+	switch cmd.kind {
+	case "index":
+		return []string{fmt.Sprintf("CREATE INDEX %s ON %s (%s)", g.quote(cmd.name), table, g.columnList(cmd.columns))}, nil
+	case "unique":
+		return []string{fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)", g.quote(cmd.name), table, g.columnList(cmd.columns))}, nil
+
+	case "primary":
+		if g.name() == "sqlite" {
+			return nil, fmt.Errorf("schema: sqlite cannot add a primary key to %s", b.table)
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s PRIMARY KEY (%s)", table, g.quote(cmd.name), g.columnList(cmd.columns))}, nil
+
+	case "foreign":
+		if g.name() == "sqlite" {
+			return nil, fmt.Errorf("schema: sqlite cannot add a foreign key to %s", b.table)
+		}
+		fk, err := g.foreignKey(cmd)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s ADD %s", table, fk)}, nil
+
+	case "drop_column":
+		var statements []string
+		for _, c := range cmd.columns {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, g.quote(c)))
+		}
+		return statements, nil
+
+	case "rename_column":
+		if g.name() == "sqlserver" {
+			return []string{fmt.Sprintf("EXEC sp_rename %s, %s, 'COLUMN'",
+				literal(b.table+"."+cmd.columns[0]), literal(cmd.to))}, nil
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, g.quote(cmd.columns[0]), g.quote(cmd.to))}, nil
+
+	case "drop_index":
+		switch g.name() {
+		case "mysql", "sqlserver":
+			return []string{fmt.Sprintf("DROP INDEX %s ON %s", g.quote(cmd.name), table)}, nil
+		}
+		return []string{fmt.Sprintf("DROP INDEX %s", g.quote(cmd.name))}, nil
+
+	case "drop_foreign":
+		switch g.name() {
+		case "sqlite":
+			return nil, fmt.Errorf("schema: sqlite cannot drop a foreign key from %s", b.table)
+		case "mysql":
+			return []string{fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", table, g.quote(cmd.name))}, nil
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", table, g.quote(cmd.name))}, nil
+	}
+	return nil, fmt.Errorf("schema: unknown command %q", cmd.kind)
+}
+
+func (g grammar) foreignKey(cmd command) (string, error) {
+	fk := cmd.foreign
+	if fk.on == "" || len(fk.references) == 0 {
+		return "", fmt.Errorf("schema: foreign key %s needs References and On", cmd.name)
+	}
+	sql := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		g.quote(cmd.name), g.columnList(fk.columns), g.quote(fk.on), g.columnList(fk.references))
+	if fk.onDelete != "" {
+		sql += " ON DELETE " + fk.onDelete
+	}
+	if fk.onUpdate != "" {
+		sql += " ON UPDATE " + fk.onUpdate
+	}
+	return sql, nil
+}
+
+func (g grammar) compileDrop(table string, ifExists bool) string {
+	if ifExists {
+		return "DROP TABLE IF EXISTS " + g.quote(table)
+	}
+	return "DROP TABLE " + g.quote(table)
+}
+
+func (g grammar) compileRename(from, to string) string {
+	switch g.name() {
+	case "mysql":
+		return fmt.Sprintf("RENAME TABLE %s TO %s", g.quote(from), g.quote(to))
+	case "sqlserver":
+		return fmt.Sprintf("EXEC sp_rename %s, %s", literal(from), literal(to))
+	}
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", g.quote(from), g.quote(to))
+}
+
+// compileHasTable and compileHasColumn return COUNT queries taking the
+// table (and column) as bind arguments
+func (g grammar) compileHasTable() string {
+	p := g.d.Placeholder
+	switch g.name() {
+	case "sqlite":
+		return "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = " + p(1)
+	case "postgres":
+		return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = " + p(1)
+	case "sqlserver":
+		return "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_NAME = " + p(1)
+	}
+	return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = " + p(1)
+}
+
+func (g grammar) compileHasColumn() string {
+	p := g.d.Placeholder
+	switch g.name() {
+	case "sqlite":
+		return fmt.Sprintf("SELECT COUNT(*) FROM pragma_table_info(%s) WHERE name = %s", p(1), p(2))
+	case "postgres":
+		return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = %s AND column_name = %s", p(1), p(2))
+	case "sqlserver":
+		return fmt.Sprintf("SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_NAME = %s AND COLUMN_NAME = %s", p(1), p(2))
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = %s AND column_name = %s", p(1), p(2))
+}
+
+// columnDefinition renders "name TYPE [NOT] NULL [DEFAULT x]" with the
+// auto-increment syntax of the dialect
+func (g grammar) columnDefinition(c *ColumnDefinition) string {
+	sql := g.quote(c.name) + " " + g.columnType(c)
+
+	switch c.kind {
+	case typeBigIncrements, typeIncrements:
+		switch g.name() {
+		case "postgres":
+			return sql + " PRIMARY KEY"
+		case "sqlite":
+			return sql + " PRIMARY KEY AUTOINCREMENT"
+		case "sqlserver":
+			return sql + " IDENTITY(1,1) PRIMARY KEY"
+		}
+		return sql + " NOT NULL AUTO_INCREMENT PRIMARY KEY"
+	}
+
+	if c.nullable {
+		sql += " NULL"
+	} else {
+		sql += " NOT NULL"
+	}
+	if c.hasDefault {
+		sql += " DEFAULT " + g.defaultValue(c.def)
+	}
+	return sql
+}
+
+func (g grammar) columnType(c *ColumnDefinition) string {
+	unsigned := ""
+	if c.unsigned && g.name() == "mysql" {
+		unsigned = " UNSIGNED"
+	}
+
+	switch c.kind {
+	case typeBigIncrements:
+		return g.pick("BIGINT UNSIGNED", "BIGSERIAL", "INTEGER", "BIGINT")
+	case typeIncrements:
+		return g.pick("INT UNSIGNED", "SERIAL", "INTEGER", "INT")
+	case typeString:
+		if g.name() == "sqlserver" {
+			return fmt.Sprintf("NVARCHAR(%d)", c.length)
+		}
+		return fmt.Sprintf("VARCHAR(%d)", c.length)
+	case typeChar:
+		if g.name() == "sqlserver" {
+			return fmt.Sprintf("NCHAR(%d)", c.length)
+		}
+		return fmt.Sprintf("CHAR(%d)", c.length)
+	case typeText:
+		return g.pick("TEXT", "TEXT", "TEXT", "NVARCHAR(MAX)")
+	case typeLongText:
+		return g.pick("LONGTEXT", "TEXT", "TEXT", "NVARCHAR(MAX)")
+	case typeInteger:
+		return g.pick("INT", "INTEGER", "INTEGER", "INT") + unsigned
+	case typeBigInteger:
+		return g.pick("BIGINT", "BIGINT", "INTEGER", "BIGINT") + unsigned
+	case typeSmallInteger:
+		return g.pick("SMALLINT", "SMALLINT", "INTEGER", "SMALLINT") + unsigned
+	case typeTinyInteger:
+		return g.pick("TINYINT", "SMALLINT", "INTEGER", "TINYINT") + unsigned
+	case typeBoolean:
+		return g.pick("TINYINT(1)", "BOOLEAN", "BOOLEAN", "BIT")
+	case typeDecimal:
+		return fmt.Sprintf("DECIMAL(%d, %d)", c.precision, c.scale) + unsigned
+	case typeFloat:
+		return g.pick("FLOAT", "REAL", "REAL", "REAL")
+	case typeDouble:
+		return g.pick("DOUBLE", "DOUBLE PRECISION", "DOUBLE", "FLOAT")
+	case typeDate:
+		return "DATE"
+	case typeDateTime:
+		return g.pick("DATETIME", "TIMESTAMP", "DATETIME", "DATETIME2")
+	case typeTime:
+		return "TIME"
+	case typeTimestamp:
+		return g.pick("TIMESTAMP", "TIMESTAMP", "DATETIME", "DATETIME2")
+	case typeJSON:
+		return g.pick("JSON", "JSONB", "TEXT", "NVARCHAR(MAX)")
+	case typeUUID:
+		return g.pick("CHAR(36)", "UUID", "VARCHAR(36)", "UNIQUEIDENTIFIER")
+	case typeBinary:
+		return g.pick("BLOB", "BYTEA", "BLOB", "VARBINARY(MAX)")
+	}
+	return strings.ToUpper(c.kind)
+}
+
+// pick chooses by dialect: MySQL, Postgres, SQLite, SQL Server
+func (g grammar) pick(mysql, postgres, sqlite, sqlserver string) string {
+	switch g.name() {
+	case "postgres":
+		return postgres
+	case "sqlite":
+		return sqlite
+	case "sqlserver":
+		return sqlserver
+	}
+	return mysql
+}
+
+func (g grammar) defaultValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case Expression:
+		return string(v)
+	case bool:
+		if g.name() == "postgres" {
+			return strings.ToUpper(strconv.FormatBool(v))
+		}
+		if v {
+			return "1"
+		}
+		return "0"
+	case string:
+		return literal(v)
+	case time.Time:
+		return literal(v.Format("2006-01-02 15:04:05"))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+	return literal(fmt.Sprint(v))
+}
+
+// literal quotes a string as SQL
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"database/sql"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"mygola/pkg/database"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func createPosts(t *Blueprint) {
+	t.ID()
+	t.String("title", 255)
+	t.String("slug").Unique()
+	t.Text("body").Nullable()
+	t.Decimal("price", 8, 2).Default(0)
+	t.Boolean("published").Default(false)
+	t.JSON("meta").Nullable()
+	t.ForeignID("user_id").Constrained().CascadeOnDelete()
+	t.Timestamps()
+	t.SoftDeletes()
+	t.Index("published", "created_at")
+}
+
+func alterPosts(t *Blueprint) {
+	t.String("subtitle").Nullable()
+	t.Integer("views").Default(0).Index()
+	t.RenameColumn("body", "content")
+	t.DropColumn("meta")
+	t.DropIndex("published", "created_at")
+}
+
+// TestGrammarSQL compares the DDL of each dialect with
+// testdata/<dialect>.golden; go test -update rewrites the files
+func TestGrammarSQL(t *testing.T) {
+	dialects := []database.Dialect{database.MySQLDialect{}, database.PostgresDialect{}, database.SQLiteDialect{}, database.SQLServerDialect{}}
+	for _, d := range dialects {
+		t.Run(d.Name(), func(t *testing.T) {
+			builder := NewBuilder(nil, d)
+			var got strings.Builder
+			for _, step := range []struct {
+				title  string
+				create bool
+				fn     func(t *Blueprint)
+			}{
+				{"create posts", true, createPosts},
+				{"alter posts", false, alterPosts},
+			} {
+				statements, err := builder.ToSQL("posts", step.create, step.fn)
+				if err != nil {
+					t.Fatalf("%s: %v", step.title, err)
+				}
+				got.WriteString("-- " + step.title + "\n")
+				for _, statement := range statements {
+					got.WriteString(statement + ";\n")
+				}
+				got.WriteString("\n")
+			}
+
+			path := filepath.Join("testdata", d.Name()+".golden")
+			if *update {
+				if err := os.MkdirAll("testdata", 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(got.String()), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("DDL differs from %s (run go test -update after checking it):\n%s", path, got.String())
+			}
+		})
+	}
+}
+
+func TestBuilderOnSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	builder := NewBuilder(db, database.SQLiteDialect{})
+
+	if err := builder.Create("users", func(t *Blueprint) {
+		t.ID()
+		t.String("email").Unique()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.Create("posts", createPosts); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.Table("posts", alterPosts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, check := range []struct {
+		column string
+		want   bool
+	}{
+		{"title", true}, {"subtitle", true}, {"content", true}, {"body", false}, {"meta", false}, {"deleted_at", true},
+	} {
+		has, err := builder.HasColumn("posts", check.column)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != check.want {
+			t.Errorf("HasColumn(posts, %s) = %v, want %v", check.column, has, check.want)
+		}
+	}
+
+	// The foreign key cascades
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO users (email) VALUES ('ada@example.com')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO posts (title, slug, user_id) VALUES ('Hello', 'hello', 1)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO posts (title, slug, user_id) VALUES ('Again', 'hello', 1)"); err == nil {
+		t.Error("the unique index on slug was not created")
+	}
+	if _, err := db.Exec("DELETE FROM users"); err != nil {
+		t.Fatal(err)
+	}
+	var posts int
+	if err := db.QueryRow("SELECT COUNT(*) FROM posts").Scan(&posts); err != nil || posts != 0 {
+		t.Errorf("posts after deleting their user: %d, %v", posts, err)
+	}
+
+	// SQLite cannot add constraints to an existing table
+	if err := builder.Table("posts", func(t *Blueprint) {
+		t.Foreign("user_id").References("id").On("users")
+	}); err == nil {
+		t.Error("adding a foreign key on sqlite did not fail")
+	}
+
+	if err := builder.DropIfExists("posts"); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := builder.HasTable("posts"); err != nil || has {
+		t.Errorf("HasTable(posts) after drop = %v, %v", has, err)
+	}
+}
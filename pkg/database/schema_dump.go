@@ -0,0 +1,208 @@
+// pkg/database/schema_dump.go
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// autoIncrementOption is the counter MySQL prints in SHOW CREATE TABLE
+var autoIncrementOption = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// UseSchemaDump sets the file written by schema:dump, conventionally
+// database/schema/<connection>.sql. On a database where no migration ran
+// yet, RunMigrations loads it first and then runs only the migrations
+// newer than the dump.
+func (m *Migrator) UseSchemaDump(path string) *Migrator {
+	m.schemaDump = path
+	return m
+}
+
+// SchemaSQL returns the DDL of every table, view, index and trigger except
+// the migrations table, read from the catalog of a MySQL or SQLite
+// database. Postgres schemas are dumped with pg_dump by schema:dump
+// instead, and SQL Server schemas cannot be dumped.
+func (m *Migrator) SchemaSQL() (string, error) {
+	var statements []string
+	switch m.dialect.Name() {
+	case "sqlite":
+		rows, err := m.db.Query(`SELECT sql FROM sqlite_master
+			WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND tbl_name <> 'migrations'
+			ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, rowid`)
+		if err != nil {
+			return "", err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var statement string
+			if err := rows.Scan(&statement); err != nil {
+				return "", err
+			}
+			statements = append(statements, statement)
+		}
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+
+	case "mysql":
+		tables, err := m.queryColumns("SHOW FULL TABLES", 0, 1)
+		if err != nil {
+			return "", err
+		}
+		statements = append(statements, "SET FOREIGN_KEY_CHECKS = 0")
+		var views []string
+		for _, table := range tables {
+			if table[0] == "migrations" {
+				continue
+			}
+			if table[1] == "VIEW" {
+				views = append(views, table[0])
+				continue
+			}
+			create, err := m.queryColumns("SHOW CREATE TABLE "+m.dialect.Quote(table[0]), 1)
+			if err != nil {
+				return "", err
+			}
+			statements = append(statements, autoIncrementOption.ReplaceAllString(create[0][0], ""))
+		}
+		for _, view := range views {
+			create, err := m.queryColumns("SHOW CREATE VIEW "+m.dialect.Quote(view), 1)
+			if err != nil {
+				return "", err
+			}
+			statements = append(statements, create[0][0])
+		}
+		triggers, err := m.queryColumns("SHOW TRIGGERS", 0)
+		if err != nil {
+			return "", err
+		}
+		for _, trigger := range triggers {
+			create, err := m.queryColumns("SHOW CREATE TRIGGER "+m.dialect.Quote(trigger[0]), 2)
+			if err != nil {
+				return "", err
+			}
+			statements = append(statements, create[0][0])
+		}
+		statements = append(statements, "SET FOREIGN_KEY_CHECKS = 1")
+
+	case "postgres":
+		return "", fmt.Errorf("postgres schemas are dumped with pg_dump, not read from the catalog")
+
+	default:
+		return "", fmt.Errorf("schema dumps of %s databases are not supported", m.dialect.Name())
+	}
+
+	return joinStatements(statements), nil
+}
+
+// MigrationRowsSQL returns INSERTs recreating the rows of the migrations
+// table, so a loaded dump counts as those migrations having run
+func (m *Migrator) MigrationRowsSQL() (string, error) {
+	run, err := m.GetRunMigrations()
+	if err != nil {
+		return "", err
+	}
+
+	statements := make([]string, 0, len(run))
+	for _, migration := range run {
+		checksum := "NULL"
+		if migration.Checksum != "" {
+			checksum = quoteString(migration.Checksum)
+		}
+		statements = append(statements, fmt.Sprintf("INSERT INTO migrations (name, batch, checksum) VALUES (%s, %d, %s)",
+			quoteString(migration.Name), migration.Batch, checksum))
+	}
+	return joinStatements(statements), nil
+}
+
+// PruneMigrations deletes the files of the migrations that have run, which
+// a schema dump now covers: SQL pairs and Go files named after the
+// migration. It returns the deleted files.
+func (m *Migrator) PruneMigrations(migrationsPath string) ([]string, error) {
+	run, err := m.GetRunMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for _, migration := range run {
+		for _, suffix := range []string{"_up.sql", "_down.sql", ".sql", ".go"} {
+			file := filepath.Join(migrationsPath, migration.Name+suffix)
+			err := os.Remove(file)
+			if err == nil {
+				pruned = append(pruned, file)
+			} else if !os.IsNotExist(err) {
+				return pruned, err
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// loadSchemaDump runs the schema dump, if there is one, on a database
+// without migrations. It reports whether a dump was loaded.
+func (m *Migrator) loadSchemaDump(runMigrations []Migration) (bool, error) {
+	if m.schemaDump == "" || len(runMigrations) > 0 {
+		return false, nil
+	}
+	content, err := os.ReadFile(m.schemaDump)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Printf("Loading stored database schema: %s\n", m.schemaDump)
+	if err := m.execute(string(content), func(exec Executor) error { return nil }); err != nil {
+		return false, fmt.Errorf("failed to load schema dump %s: %v", m.schemaDump, err)
+	}
+	return true, nil
+}
+
+// queryColumns returns the given columns of every row of a query, whatever
+// the number of columns it has
+func (m *Migrator) queryColumns(query string, columns ...int) ([][]string, error) {
+	rows, err := m.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result [][]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(names))
+		dest := make([]any, len(names))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = values[column].String
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func joinStatements(statements []string) string {
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, ";\n\n") + ";\n"
+}
+
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,146 @@
+// pkg/database/scope.go
+package database
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Scope adds conditions to a query, see ORM.AddGlobalScope
+type Scope func(q *Query)
+
+// scopeRegistry is shared by an ORM and its transactions
+type scopeRegistry struct {
+	mu     sync.RWMutex
+	scopes map[string]map[string]Scope // table -> name -> scope
+}
+
+// AddGlobalScope applies a scope to every query on a table until it is
+// removed with WithoutGlobalScope, e.g. for multi-tenant filtering:
+//
+//	orm.AddGlobalScope("posts", "tenant", func(q *database.Query) {
+//		q.Where("posts.tenant_id", "=", tenantID)
+//	})
+//
+// A scope added again under the same name replaces the previous one.
+func (o *ORM) AddGlobalScope(table, name string, scope Scope) {
+	r := o.scopes
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scopes[table] == nil {
+		r.scopes[table] = make(map[string]Scope)
+	}
+	r.scopes[table][name] = scope
+}
+
+// RemoveGlobalScope unregisters a global scope
+func (o *ORM) RemoveGlobalScope(table, name string) {
+	o.scopes.mu.Lock()
+	defer o.scopes.mu.Unlock()
+	delete(o.scopes.scopes[table], name)
+}
+
+// globalScopes returns the scopes of a table ordered by name, leaving out
+// the skipped ones
+func (o *ORM) globalScopes(table string, skip map[string]bool) []Scope {
+	r := o.scopes
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.scopes[table]))
+	for name := range r.scopes[table] {
+		if !skip[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	scopes := make([]Scope, len(names))
+	for i, name := range names {
+		scopes[i] = r.scopes[table][name]
+	}
+	return scopes
+}
+
+// Model starts a query on a model's table. Unlike Table it knows the model
+// type, so soft deleted rows are left out of every operation.
+func (o *ORM) Model(model Model) *Query {
+	q := o.Table(model.TableName())
+	q.model = indirect(reflect.TypeOf(model))
+	return q
+}
+
+// WithoutGlobalScope skips the named global scopes for this query
+func (q *Query) WithoutGlobalScope(names ...string) *Query {
+	if q.removedScopes == nil {
+		q.removedScopes = make(map[string]bool)
+	}
+	for _, name := range names {
+		q.removedScopes[name] = true
+	}
+	return q
+}
+
+// WithoutGlobalScopes skips every global scope, soft deletes included
+func (q *Query) WithoutGlobalScopes() *Query {
+	q.noScopes = true
+	return q
+}
+
+// modelFrom remembers the model type of a destination, so queries started
+// with Table still hide soft deleted rows when they scan into models, and
+// queries started with ORM.With read the model's table
+func (q *Query) modelFrom(dest interface{}) {
+	model, ok := modelOf(reflect.TypeOf(dest))
+	if !ok {
+		return
+	}
+	if q.model == nil {
+		t := indirect(reflect.TypeOf(dest))
+		if t.Kind() == reflect.Slice {
+			t = indirect(t.Elem())
+		}
+		q.model = t
+	}
+	if q.table == "" {
+		q.table = model.TableName()
+		q.check(q.table)
+	}
+}
+
+func (q *Query) scopeRemoved(name string) bool {
+	return q.noScopes || q.removedScopes[name]
+}
+
+// scoped returns a copy of the query with the global scopes applied. The
+// query's own conditions are grouped so an OrWhere cannot escape a scope.
+func (q *Query) scoped() *Query {
+	s := *q
+
+	var scopes []Scope
+	if !q.noScopes {
+		scopes = q.orm.globalScopes(q.table, q.removedScopes)
+	}
+
+	soft := q.softDeleting()
+	if len(scopes) == 0 && !soft && !q.onlyTrashed {
+		return &s
+	}
+
+	s.wheres = nil
+	if len(q.wheres) > 0 {
+		s.wheres = []whereClause{{boolean: "AND", kind: "group", nested: q.wheres}}
+	}
+	for _, scope := range scopes {
+		scope(&s)
+	}
+	deletedAt := q.table + "." + deletedAtColumn
+	switch {
+	case q.onlyTrashed:
+		s.WhereNotNull(deletedAt)
+	case soft:
+		s.WhereNull(deletedAt)
+	}
+	return &s
+}
@@ -5,102 +5,250 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
+
+	"mygola/pkg/database"
+	"mygola/pkg/database/schema"
 )
 
-type Seeder struct {
-	db *sql.DB
+// Seeder fills tables with data. Run gets the seeder's transaction and the
+// dialect of its connection, for the placeholders of its statements.
+type Seeder interface {
+	Run(db database.Executor, dialect database.Dialect) error
 }
 
-func NewSeeder(db *sql.DB) *Seeder {
-	return &Seeder{db: db}
+// DependentSeeder is a Seeder that needs other seeders, by name, to run
+// first, e.g. a PostsTableSeeder that depends on "UsersTableSeeder"
+type DependentSeeder interface {
+	Seeder
+	DependsOn() []string
 }
 
-func (s *Seeder) Run() error {
-	log.Println("Seeding database...")
-
-	// Run all seeders
-	seeders := []func() error{
-		s.UsersTableSeeder,
-		s.PostsTableSeeder,
-		// Add more seeders here
+// Name is what a seeder is registered and selected (db:seed --class) by:
+// its type name, e.g. "ProductSeeder"
+func Name(seeder Seeder) string {
+	t := reflect.TypeOf(seeder)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
+	return t.Name()
+}
 
+// Registry holds the application's seeders in registration order
+type Registry struct {
+	mu      sync.RWMutex
+	seeders map[string]Seeder
+	order   []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{seeders: make(map[string]Seeder)}
+}
+
+// DefaultRegistry is bound by SeederServiceProvider
+var DefaultRegistry = NewRegistry()
+
+// Register adds seeders; registering one again replaces it
+func (r *Registry) Register(seeders ...Seeder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	for _, seeder := range seeders {
-		if err := seeder(); err != nil {
-			return err
+		name := Name(seeder)
+		if _, ok := r.seeders[name]; !ok {
+			r.order = append(r.order, name)
 		}
+		r.seeders[name] = seeder
 	}
+}
 
-	log.Println("Database seeded successfully")
-	return nil
+// Names returns the registered seeder names in registration order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.order...)
+}
+
+func (r *Registry) get(name string) (Seeder, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seeder, ok := r.seeders[name]
+	return seeder, ok
+}
+
+// Runner runs registered seeders. Each seeder runs in its own transaction
+// together with its row in the seeders table, so a seeder that fails
+// leaves nothing behind and one that succeeded is skipped next time.
+type Runner struct {
+	db       *sql.DB
+	dialect  database.Dialect
+	registry *Registry
+	rerun    bool
 }
 
-func (s *Seeder) UsersTableSeeder() error {
-	log.Println("Seeding users table...")
+// NewRunner needs the dialect of db, for the seeders table and the seeders
+func NewRunner(db *sql.DB, dialect database.Dialect) *Runner {
+	return &Runner{db: db, dialect: dialect, registry: DefaultRegistry}
+}
 
-	// Insert sample users
-	users := []struct {
-		ID       string
-		Name     string
-		Email    string
-		Password string
-	}{
-		{"1", "John Doe", "john@example.com", "password123"},
-		{"2", "Jane Smith", "jane@example.com", "password123"},
-		{"3", "Bob Johnson", "bob@example.com", "password123"},
+// UseRegistry replaces DefaultRegistry
+func (r *Runner) UseRegistry(registry *Registry) *Runner {
+	r.registry = registry
+	return r
+}
+
+// Rerun runs seeders again even if they ran before
+func (r *Runner) Rerun(rerun bool) *Runner {
+	r.rerun = rerun
+	return r
+}
+
+// Run runs the named seeders, or all registered ones when none are named,
+// after the seeders they depend on
+func (r *Runner) Run(names ...string) error {
+	if len(names) == 0 {
+		names = r.registry.Names()
+	}
+	order, err := r.resolve(names)
+	if err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		log.Println("No seeders registered")
+		return nil
 	}
 
-	for _, user := range users {
-		query := `INSERT INTO users (id, name, email, password) VALUES (?, ?, ?, ?)`
-		_, err := s.db.Exec(query, user.ID, user.Name, user.Email, user.Password)
-		if err != nil {
-			return fmt.Errorf("failed to seed users: %v", err)
+	if err := r.createSeedersTable(); err != nil {
+		return fmt.Errorf("failed to create seeders table: %v", err)
+	}
+	ran, err := r.ranSeeders()
+	if err != nil {
+		return fmt.Errorf("failed to get run seeders: %v", err)
+	}
+
+	log.Println("Seeding database...")
+	for _, name := range order {
+		if ran[name] && !r.rerun {
+			log.Printf("Skipping %s, already seeded", name)
+			continue
+		}
+		log.Printf("Seeding: %s", name)
+
+		start := time.Now()
+		if err := r.runSeeder(name); err != nil {
+			return fmt.Errorf("seeder %s failed: %v", name, err)
 		}
+		log.Printf("Seeded:  %s (%s)", name, time.Since(start).Round(time.Millisecond))
 	}
 
+	log.Println("Database seeded successfully")
 	return nil
 }
 
-func (s *Seeder) PostsTableSeeder() error {
-	log.Println("Seeding posts table...")
+// resolve orders seeders after their dependencies
+func (r *Runner) resolve(names []string) ([]string, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var order []string
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("seeder dependency cycle: %s -> %s", strings.Join(path, " -> "), name)
+		}
+		seeder, ok := r.registry.get(name)
+		if !ok {
+			if len(path) > 0 {
+				return fmt.Errorf("seeder %q, needed by %s, is not registered", name, path[len(path)-1])
+			}
+			return fmt.Errorf("seeder %q is not registered", name)
+		}
 
-	// Insert sample posts
-	posts := []struct {
-		ID      string
-		Title   string
-		Content string
-		UserID  string
-	}{
-		{"1", "First Post", "This is the first post", "1"},
-		{"2", "Second Post", "This is the second post", "1"},
-		{"3", "Third Post", "This is the third post", "2"},
+		state[name] = visiting
+		if dependent, ok := seeder.(DependentSeeder); ok {
+			for _, dependency := range dependent.DependsOn() {
+				if err := visit(dependency, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = done
+		order = append(order, name)
+		return nil
 	}
 
-	for _, post := range posts {
-		query := `INSERT INTO posts (id, title, content, user_id) VALUES (?, ?, ?, ?)`
-		_, err := s.db.Exec(query, post.ID, post.Title, post.Content, post.UserID)
-		if err != nil {
-			return fmt.Errorf("failed to seed posts: %v", err)
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
 		}
 	}
-
-	return nil
+	return order, nil
 }
 
-// Add more seeders as needed
+func (r *Runner) runSeeder(name string) (err error) {
+	seeder, _ := r.registry.get(name)
 
-// Helper function to generate random data
-func randomString(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
 	}
-	return string(b)
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = seeder.Run(tx, r.dialect); err != nil {
+		return err
+	}
+	p1 := r.dialect.Placeholder(1)
+	if _, err = tx.Exec("DELETE FROM seeders WHERE name = "+p1, name); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("INSERT INTO seeders (name) VALUES ("+p1+")", name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Runner) createSeedersTable() error {
+	builder := schema.NewBuilder(r.db, r.dialect)
+	exists, err := builder.HasTable("seeders")
+	if err != nil || exists {
+		return err
+	}
+	return builder.Create("seeders", func(t *schema.Blueprint) {
+		t.String("name").Primary()
+		t.Timestamp("ran_at").UseCurrent()
+	})
 }
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+func (r *Runner) ranSeeders() (map[string]bool, error) {
+	rows, err := r.db.Query("SELECT name FROM seeders")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ran := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		ran[name] = true
+	}
+	return ran, rows.Err()
 }
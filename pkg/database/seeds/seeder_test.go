@@ -0,0 +1,131 @@
+package seeds
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"mygola/pkg/database"
+)
+
+// numberedDialect is sqlite with numbered ?NNN placeholders, so statements
+// only work when they take their placeholders from the dialect
+type numberedDialect struct {
+	database.SQLiteDialect
+}
+
+func (numberedDialect) Placeholder(n int) string { return fmt.Sprintf("?%d", n) }
+
+// ran records the order the test seeders ran in
+var ran []string
+
+type UsersSeeder struct{}
+
+func (UsersSeeder) Run(db database.Executor, dialect database.Dialect) error {
+	ran = append(ran, "UsersSeeder")
+	// Arguments are bound by number, not by position
+	query := fmt.Sprintf("INSERT INTO users (name, email) VALUES (%s, %s)", dialect.Placeholder(2), dialect.Placeholder(1))
+	_, err := db.Exec(query, "ada@example.com", "Ada")
+	return err
+}
+
+type PostsSeeder struct{}
+
+func (PostsSeeder) DependsOn() []string { return []string{"UsersSeeder"} }
+
+func (PostsSeeder) Run(db database.Executor, dialect database.Dialect) error {
+	ran = append(ran, "PostsSeeder")
+	_, err := db.Exec("INSERT INTO posts (title) VALUES ("+dialect.Placeholder(1)+")", "Hello")
+	return err
+}
+
+type BrokenSeeder struct{}
+
+func (BrokenSeeder) Run(db database.Executor, dialect database.Dialect) error {
+	ran = append(ran, "BrokenSeeder")
+	if _, err := db.Exec("INSERT INTO posts (title) VALUES ("+dialect.Placeholder(1)+")", "half done"); err != nil {
+		return err
+	}
+	return errors.New("boom")
+}
+
+func newSQLiteRunner(t *testing.T) (*Runner, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, statement := range []string{
+		"CREATE TABLE users (name TEXT, email TEXT)",
+		"CREATE TABLE posts (title TEXT)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := NewRegistry()
+	registry.Register(PostsSeeder{}, UsersSeeder{}, BrokenSeeder{})
+	ran = nil
+	return NewRunner(db, numberedDialect{}).UseRegistry(registry), db
+}
+
+func count(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRunnerUsesTheConnectionDialect(t *testing.T) {
+	runner, db := newSQLiteRunner(t)
+
+	if err := runner.Run("PostsSeeder"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ran, []string{"UsersSeeder", "PostsSeeder"}) {
+		t.Fatalf("ran %v, want the dependency first", ran)
+	}
+	var name, email string
+	if err := db.QueryRow("SELECT name, email FROM users").Scan(&name, &email); err != nil || name != "Ada" || email != "ada@example.com" {
+		t.Fatalf("seeded user %q %q, %v", name, email, err)
+	}
+
+	// Seeded ones are skipped, unless rerun
+	ran = nil
+	if err := runner.Run("PostsSeeder"); err != nil || len(ran) != 0 {
+		t.Fatalf("second run ran %v, %v", ran, err)
+	}
+	if err := runner.Rerun(true).Run("UsersSeeder"); err != nil || count(t, db, "users") != 2 {
+		t.Fatalf("rerun: %v, %d users", err, count(t, db, "users"))
+	}
+}
+
+func TestFailedSeederLeavesNothingBehind(t *testing.T) {
+	runner, db := newSQLiteRunner(t)
+
+	if err := runner.Run("BrokenSeeder"); err == nil {
+		t.Fatal("the failing seeder reported success")
+	}
+	if n := count(t, db, "posts"); n != 0 {
+		t.Fatalf("%d posts left by the failed seeder", n)
+	}
+	if n := count(t, db, "seeders"); n != 0 {
+		t.Fatalf("the failed seeder was recorded as run")
+	}
+}
+
+func TestResolveReportsMissingSeeders(t *testing.T) {
+	runner, _ := newSQLiteRunner(t)
+	if err := runner.Run("ProductSeeder"); err == nil {
+		t.Fatal("ran an unregistered seeder")
+	}
+}
@@ -0,0 +1,109 @@
+// pkg/database/soft_deletes.go
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// SoftDeleteScope is the name of the scope hiding soft deleted rows, for use
+// with WithoutGlobalScope
+const SoftDeleteScope = "soft_deletes"
+
+const deletedAtColumn = "deleted_at"
+
+// SoftDeletes makes ORM.Delete set deleted_at instead of removing the row,
+// and hides such rows from model queries. Embed it in a model whose table
+// has a nullable deleted_at column:
+//
+//	type Post struct {
+//		ID int `db:"id"`
+//		database.SoftDeletes
+//	}
+type SoftDeletes struct {
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// Trashed reports whether the model is soft deleted
+func (s *SoftDeletes) Trashed() bool {
+	return s.DeletedAt != nil
+}
+
+func (s *SoftDeletes) usesSoftDeletes() {}
+
+type softDeletable interface {
+	usesSoftDeletes()
+}
+
+func isSoftDeletable(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := reflect.New(indirect(t)).Interface().(softDeletable)
+	return ok
+}
+
+// softDeleting reports whether the query works on a soft deleting model
+// with the scope in place
+func (q *Query) softDeleting() bool {
+	return isSoftDeletable(q.model) && !q.scopeRemoved(SoftDeleteScope)
+}
+
+// WithTrashed includes soft deleted rows
+func (q *Query) WithTrashed() *Query {
+	return q.WithoutGlobalScope(SoftDeleteScope)
+}
+
+// OnlyTrashed returns soft deleted rows only
+func (q *Query) OnlyTrashed() *Query {
+	q.WithoutGlobalScope(SoftDeleteScope)
+	q.onlyTrashed = true
+	return q
+}
+
+// Restore clears deleted_at on the matching soft deleted rows
+func (q *Query) Restore() (int64, error) {
+	return q.OnlyTrashed().Update(map[string]interface{}{deletedAtColumn: nil})
+}
+
+// Restore un-deletes a soft deleted model
+func (o *ORM) Restore(model Model) error {
+	val, err := modelValue(model)
+	if err != nil {
+		return err
+	}
+	if !isSoftDeletable(val.Type()) {
+		return fmt.Errorf("database: %s does not use soft deletes", model.TableName())
+	}
+	id, ok := primaryKeyValue(model, val)
+	if !ok {
+		return fmt.Errorf("database: restore of %s without a primary key", model.TableName())
+	}
+
+	if _, err := o.Model(model).Where(primaryKey(model), "=", id).Restore(); err != nil {
+		return err
+	}
+	val.FieldByIndex(columnIndex(val.Type())[deletedAtColumn]).Set(reflect.Zero(reflect.TypeOf(&time.Time{})))
+	return nil
+}
+
+// ForceDelete removes a model's row even when it uses soft deletes
+func (o *ORM) ForceDelete(model Model) error {
+	val, err := modelValue(model)
+	if err != nil {
+		return err
+	}
+	id, ok := primaryKeyValue(model, val)
+	if !ok {
+		return fmt.Errorf("database: delete of %s without a primary key", model.TableName())
+	}
+
+	if err := o.fire(eventDeleting, model); err != nil {
+		return err
+	}
+	if _, err := o.Model(model).WithTrashed().Where(primaryKey(model), "=", id).ForceDelete(); err != nil {
+		return err
+	}
+	return o.fire(eventDeleted, model)
+}
@@ -0,0 +1,219 @@
+// pkg/database/sqlsplit.go
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitStatements splits a SQL script into statements on ";". Semicolons
+// inside string literals, quoted identifiers, comments, Postgres dollar
+// quotes ($$ ... $$) and the BEGIN ... END body of a CREATE TRIGGER do not
+// split. Two client conventions are understood as well:
+//
+//	DELIMITER //          -- MySQL: statements end with // until the next DELIMITER
+//	GO                    -- SQL Server: a line with only GO ends a batch
+//
+// Comment-only chunks are dropped and the statements are returned trimmed,
+// without their terminator.
+func SplitStatements(script string) []string {
+	s := &splitter{src: script, delimiter: ";"}
+	s.split()
+	return s.statements
+}
+
+type splitter struct {
+	src        string
+	pos        int
+	delimiter  string
+	buf        strings.Builder
+	hasCode    bool // buf holds more than comments and whitespace
+	words      int  // words seen in the statement, up to four
+	trigger    bool // the statement is CREATE [TEMP] TRIGGER
+	depth      int  // BEGIN/CASE nesting inside a trigger
+	statements []string
+}
+
+func (s *splitter) split() {
+	for s.pos < len(s.src) {
+		if s.atLineStart() && s.directive() {
+			continue
+		}
+
+		c := s.src[s.pos]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			s.quoted(string(c), string(c))
+		case c == '[':
+			s.quoted("[", "]")
+		case strings.HasPrefix(s.src[s.pos:], "--"):
+			s.comment("--", "\n")
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			s.comment("/*", "*/")
+		case c == '$' && s.dollarQuoted():
+		case s.depth == 0 && strings.HasPrefix(s.src[s.pos:], s.delimiter):
+			s.pos += len(s.delimiter)
+			s.flush()
+		case isWordStart(c):
+			s.word()
+		default:
+			s.write(s.src[s.pos : s.pos+1])
+			s.pos++
+		}
+	}
+	s.flush()
+}
+
+// directive handles DELIMITER and GO lines
+func (s *splitter) directive() bool {
+	end := strings.IndexByte(s.src[s.pos:], '\n')
+	if end < 0 {
+		end = len(s.src) - s.pos
+	}
+	line := strings.TrimSpace(s.src[s.pos : s.pos+end])
+
+	switch {
+	case strings.EqualFold(line, "GO"):
+		s.flush()
+	case len(line) > len("DELIMITER ") && strings.EqualFold(line[:len("DELIMITER ")], "DELIMITER "):
+		s.flush()
+		s.delimiter = strings.TrimSpace(line[len("DELIMITER "):])
+	default:
+		return false
+	}
+	s.pos += end
+	return true
+}
+
+func (s *splitter) atLineStart() bool {
+	i := s.pos - 1
+	for i >= 0 && (s.src[i] == ' ' || s.src[i] == '\t') {
+		i--
+	}
+	return i < 0 || s.src[i] == '\n'
+}
+
+// quoted copies a quoted literal or identifier; a doubled closing quote is
+// an escape, and so is a backslash inside MySQL-style strings
+func (s *splitter) quoted(open, close string) {
+	start := s.pos
+	s.pos += len(open)
+	for s.pos < len(s.src) {
+		if s.src[s.pos] == '\\' && open != "[" && open != "`" {
+			s.pos += 2
+			continue
+		}
+		if strings.HasPrefix(s.src[s.pos:], close) {
+			s.pos += len(close)
+			if strings.HasPrefix(s.src[s.pos:], close) && close != "]" {
+				s.pos += len(close)
+				continue
+			}
+			break
+		}
+		s.pos++
+	}
+	s.write(s.src[start:min(s.pos, len(s.src))])
+}
+
+// comment copies a comment without counting it as code
+func (s *splitter) comment(open, end string) {
+	start := s.pos
+	i := strings.Index(s.src[s.pos+len(open):], end)
+	if i < 0 {
+		s.pos = len(s.src)
+	} else {
+		s.pos += len(open) + i + len(end)
+	}
+	s.buf.WriteString(s.src[start:s.pos])
+}
+
+// dollarQuoted copies a Postgres $tag$ ... $tag$ string
+func (s *splitter) dollarQuoted() bool {
+	rest := s.src[s.pos+1:]
+	i := strings.IndexByte(rest, '$')
+	if i < 0 {
+		return false
+	}
+	for _, r := range rest[:i] {
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			return false
+		}
+	}
+	tag := s.src[s.pos : s.pos+i+2]
+	end := strings.Index(s.src[s.pos+len(tag):], tag)
+	start := s.pos
+	if end < 0 {
+		s.pos = len(s.src)
+	} else {
+		s.pos += len(tag) + end + len(tag)
+	}
+	s.write(s.src[start:s.pos])
+	return true
+}
+
+// word copies a keyword or identifier and tracks BEGIN ... END blocks of
+// CREATE TRIGGER statements
+func (s *splitter) word() {
+	start := s.pos
+	for s.pos < len(s.src) && isWordChar(s.src[s.pos]) {
+		s.pos++
+	}
+	w := strings.ToUpper(s.src[start:s.pos])
+
+	if s.words < 4 {
+		s.words++
+		if w == "TRIGGER" && s.words > 1 && strings.HasPrefix(strings.ToUpper(s.firstWord()), "CREATE") {
+			s.trigger = true
+		}
+	}
+	if s.trigger && s.delimiter == ";" {
+		switch w {
+		case "BEGIN", "CASE":
+			s.depth++
+		case "END":
+			if s.depth > 0 {
+				s.depth--
+			}
+		}
+	}
+	s.write(s.src[start:s.pos])
+}
+
+// firstWord returns the first word of the statement after any comments
+func (s *splitter) firstWord() string {
+	for _, line := range strings.Split(s.buf.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+func (s *splitter) write(text string) {
+	if strings.TrimSpace(text) != "" {
+		s.hasCode = true
+	}
+	s.buf.WriteString(text)
+}
+
+func (s *splitter) flush() {
+	if s.hasCode {
+		s.statements = append(s.statements, strings.TrimSpace(s.buf.String()))
+	}
+	s.buf.Reset()
+	s.hasCode = false
+	s.words, s.trigger, s.depth = 0, false, 0
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,197 @@
+// pkg/database/transaction.go
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Executor runs statements; both *sql.DB and *sql.Tx implement it
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// TransactionAttempts is how many times a transaction is run when it fails
+// with a deadlock or serialization error
+var TransactionAttempts = 3
+
+// TransactionBackoff is the wait before the first retry; it doubles on
+// every following attempt
+var TransactionBackoff = 50 * time.Millisecond
+
+// retryableMessages are the deadlock and serialization errors of the
+// supported drivers
+var retryableMessages = []string{
+	"deadlock",                   // MySQL 1213, Postgres 40P01, SQL Server 1205
+	"could not serialize access", // Postgres 40001
+	"serialization failure",      // MySQL/Postgres SQLSTATE 40001 text
+	"database is locked",         // SQLite busy
+	"database table is locked",   // SQLite
+	"try restarting transaction", // MySQL
+	"snapshot isolation",         // SQL Server 3960
+}
+
+// txState is shared by the ORM copies of one transaction
+type txState struct {
+	tx          *sql.Tx
+	savepoints  int
+	afterCommit []func()
+}
+
+// Transaction runs fn inside a transaction. It commits when fn returns nil
+// and rolls back when it returns an error or panics:
+//
+//	err := orm.Transaction(func(tx *database.ORM) error {
+//		if err := tx.Create(&order); err != nil {
+//			return err
+//		}
+//		tx.AfterCommit(func() { mailer.Send(receipt) })
+//		return tx.Update(&stock)
+//	})
+//
+// Use tx, not orm, inside fn. Calling Transaction on tx nests a savepoint
+// that is rolled back on its own. The outermost transaction is run again
+// up to TransactionAttempts times on deadlocks and serialization failures,
+// so fn must not have side effects outside the database; queue them with
+// AfterCommit instead.
+func (o *ORM) Transaction(fn func(tx *ORM) error) error {
+	if o.tx != nil {
+		return o.savepoint(fn)
+	}
+
+	var state *txState
+	err := transaction(o.conn, func(tx *sql.Tx) error {
+		state = &txState{tx: tx}
+		return fn(o.withTx(state))
+	})
+	if err != nil {
+		return err
+	}
+	for _, callback := range state.afterCommit {
+		callback()
+	}
+	return nil
+}
+
+// AfterCommit queues fn to run once the current transaction has committed.
+// It is dropped when the transaction, or the savepoint it was queued in,
+// rolls back. Outside a transaction fn runs right away.
+func (o *ORM) AfterCommit(fn func()) {
+	if o.tx == nil {
+		fn()
+		return
+	}
+	o.tx.afterCommit = append(o.tx.afterCommit, fn)
+}
+
+// InTransaction reports whether the ORM is bound to a transaction
+func (o *ORM) InTransaction() bool {
+	return o.tx != nil
+}
+
+func (o *ORM) withTx(state *txState) *ORM {
+	tx := *o
+	tx.db = state.tx
+	tx.tx = state
+	return &tx
+}
+
+// savepoint runs a nested Transaction
+func (o *ORM) savepoint(fn func(tx *ORM) error) error {
+	o.tx.savepoints++
+	name := fmt.Sprintf("sp%d", o.tx.savepoints)
+	queued := len(o.tx.afterCommit)
+
+	create, rollback, release := "SAVEPOINT "+name, "ROLLBACK TO SAVEPOINT "+name, "RELEASE SAVEPOINT "+name
+	if o.dialect.Name() == "sqlserver" {
+		create, rollback, release = "SAVE TRANSACTION "+name, "ROLLBACK TRANSACTION "+name, ""
+	}
+
+	if _, err := o.db.Exec(create); err != nil {
+		return wrapQueryError(err, create)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			o.db.Exec(rollback)
+			o.tx.afterCommit = o.tx.afterCommit[:queued]
+			panic(p)
+		}
+	}()
+
+	if err := fn(o); err != nil {
+		if _, rbErr := o.db.Exec(rollback); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		o.tx.afterCommit = o.tx.afterCommit[:queued]
+		return err
+	}
+	if release != "" {
+		if _, err := o.db.Exec(release); err != nil {
+			return wrapQueryError(err, release)
+		}
+	}
+	return nil
+}
+
+// transaction begins, commits or rolls back, and retries fn on deadlocks
+func transaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = runTransaction(db, fn)
+		if err == nil || attempt >= TransactionAttempts || !isRetryable(err) {
+			return err
+		}
+
+		// Back off with jitter so the competing transactions do not collide again
+		wait := TransactionBackoff << (attempt - 1)
+		time.Sleep(wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)))
+	}
+}
+
+func runTransaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("database: begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("database: commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isRetryable reports whether err is a deadlock or serialization failure
+func isRetryable(err error) bool {
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) {
+		switch state.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range retryableMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
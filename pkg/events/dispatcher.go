@@ -0,0 +1,46 @@
+// pkg/events/dispatcher.go
+package events
+
+import (
+	"sync"
+)
+
+// Listener handles a dispatched event payload
+type Listener func(payload interface{})
+
+// Dispatcher is a minimal synchronous event bus
+type Dispatcher struct {
+	listeners map[string][]Listener
+	mu        sync.RWMutex
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		listeners: make(map[string][]Listener),
+	}
+}
+
+// Listen registers a listener for the named event
+func (d *Dispatcher) Listen(event string, listener Listener) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listeners[event] = append(d.listeners[event], listener)
+}
+
+// Dispatch calls every listener of the event in registration order
+func (d *Dispatcher) Dispatch(event string, payload interface{}) {
+	d.mu.RLock()
+	listeners := append([]Listener(nil), d.listeners[event]...)
+	d.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(payload)
+	}
+}
+
+// HasListeners reports whether anything listens for the event
+func (d *Dispatcher) HasListeners(event string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.listeners[event]) > 0
+}
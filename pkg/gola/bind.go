@@ -0,0 +1,169 @@
+// pkg/gola/bind.go
+package gola
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+
+// Bind decodes the request into dst, a pointer to a struct. JSON bodies are
+// decoded with encoding/json; forms are matched on the `form` tag, then the
+// JSON name, then the field name, and uploads go into *multipart.FileHeader
+// or []*multipart.FileHeader fields.
+func (c *Context) Bind(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: expects a pointer to a struct, got %T", dst)
+	}
+
+	if strings.HasPrefix(c.Request.Header.Get("Content-Type"), "application/json") {
+		err := json.NewDecoder(c.Request.Body).Decode(dst)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("bind: decode json: %v", err)
+		}
+		return nil
+	}
+
+	if _, err := c.FormData(); err != nil {
+		return fmt.Errorf("bind: %v", err)
+	}
+
+	var files map[string][]*multipart.FileHeader
+	if c.Request.MultipartForm != nil {
+		files = c.Request.MultipartForm.File
+	}
+	return bindForm(rv.Elem(), c.Request.Form, files)
+}
+
+func bindForm(rv reflect.Value, values map[string][]string, files map[string][]*multipart.FileHeader) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindForm(fv, values, files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := formName(field)
+		if name == "-" {
+			continue
+		}
+
+		switch {
+		case field.Type == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+		case field.Type == reflect.SliceOf(fileHeaderType):
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+		default:
+			// Repeated fields may be sent as "tags" or "tags[]"
+			vals, ok := values[name]
+			if !ok {
+				vals, ok = values[name+"[]"]
+			}
+			if !ok {
+				continue
+			}
+			if err := setField(fv, vals); err != nil {
+				return fmt.Errorf("bind: field %s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func formName(field reflect.StructField) string {
+	for _, key := range []string{"form", "json"} {
+		if tag := field.Tag.Get(key); tag != "" {
+			name, _, _ := strings.Cut(tag, ",")
+			if name != "" {
+				return name
+			}
+		}
+	}
+	return field.Name
+}
+
+func setField(fv reflect.Value, vals []string) error {
+	switch fv.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setField(ptr.Elem(), vals); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setScalar(slice.Index(i), val); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	return setScalar(fv, vals[0])
+}
+
+func setScalar(fv reflect.Value, val string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Bool:
+		// Unchecked checkboxes are not sent, "on" is the browser default
+		b := val == "on" || val == "1" || val == "true"
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if val == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if val == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if val == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(val, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
@@ -4,8 +4,16 @@ package gola
 import (
 	"encoding/json"
 	"fmt"
+	"mygola/pkg/session"
 	"mygola/pkg/view"
 	"net/http"
+	"strings"
+)
+
+// Session keys used to carry a failed form submission to the next request
+const (
+	ErrorsKey   = "_errors"
+	OldInputKey = "_old_input"
 )
 
 type Context struct {
@@ -15,6 +23,11 @@ type Context struct {
 	TemplateEngine *view.TemplateEngine
 	Flash          string
 	FlashType      string
+	Session        session.Session
+
+	// Validation errors and input flashed by the previous request
+	Errors   map[string][]string
+	OldInput map[string]string
 }
 
 // JSON response
@@ -36,6 +49,132 @@ func (c *Context) Param(key string) string {
 	return c.Params[key]
 }
 
+// MaxFormMemory is how much of a multipart body is kept in memory, the
+// rest of the uploads spill to temporary files
+var MaxFormMemory int64 = 32 << 20
+
+// FormData returns the form fields and uploaded files for validation.
+// Single values are strings and single uploads *multipart.FileHeader;
+// repeated fields become []string and []*multipart.FileHeader.
+func (c *Context) FormData() (map[string]interface{}, error) {
+	r := c.Request
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(MaxFormMemory); err != nil {
+			return nil, fmt.Errorf("parse multipart form: %v", err)
+		}
+	} else if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("parse form: %v", err)
+	}
+
+	data := make(map[string]interface{})
+	for key, values := range r.Form {
+		if len(values) == 1 {
+			data[key] = values[0]
+		} else {
+			data[key] = values
+		}
+	}
+	if r.MultipartForm != nil {
+		for key, files := range r.MultipartForm.File {
+			if len(files) == 1 {
+				data[key] = files[0]
+			} else {
+				data[key] = files
+			}
+		}
+	}
+	return data, nil
+}
+
+// UseSession attaches the request session and takes over the validation
+// errors and old input flashed by the previous request
+func (c *Context) UseSession(sess session.Session) {
+	c.Session = sess
+	if sess == nil {
+		return
+	}
+
+	if flashed := sess.Get(ErrorsKey); flashed != nil {
+		c.Errors = toErrors(flashed)
+		sess.Delete(ErrorsKey)
+	}
+	if flashed := sess.Get(OldInputKey); flashed != nil {
+		c.OldInput = toStrings(flashed)
+		sess.Delete(OldInputKey)
+	}
+}
+
+// FlashInput stores validation errors and input for the next request
+func (c *Context) FlashInput(errors map[string][]string, input map[string]string) {
+	if c.Session == nil {
+		return
+	}
+	c.Session.Set(ErrorsKey, errors)
+	c.Session.Set(OldInputKey, input)
+}
+
+// Old returns the flashed input of a field, for refilling forms
+func (c *Context) Old(key string) string {
+	return c.OldInput[key]
+}
+
+// WantsJSON reports API style requests: an Accept header asking for JSON,
+// an XMLHttpRequest, a JSON body or a path under /api/
+func (c *Context) WantsJSON() bool {
+	r := c.Request
+	return strings.Contains(r.Header.Get("Accept"), "application/json") ||
+		r.Header.Get("X-Requested-With") == "XMLHttpRequest" ||
+		strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") ||
+		strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+// Redirect sends a redirect response
+func (c *Context) Redirect(status int, url string) {
+	http.Redirect(c.Writer, c.Request, url, status)
+}
+
+// Back redirects to the referring page, or fallback without one
+func (c *Context) Back(fallback string) {
+	url := c.Request.Referer()
+	if url == "" {
+		url = fallback
+	}
+	c.Redirect(http.StatusSeeOther, url)
+}
+
+// Sessions that serialize their data hand maps back untyped
+func toErrors(value interface{}) map[string][]string {
+	switch v := value.(type) {
+	case map[string][]string:
+		return v
+	case map[string]interface{}:
+		out := make(map[string][]string, len(v))
+		for field, messages := range v {
+			if list, ok := messages.([]interface{}); ok {
+				for _, m := range list {
+					out[field] = append(out[field], fmt.Sprint(m))
+				}
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func toStrings(value interface{}) map[string]string {
+	switch v := value.(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		out := make(map[string]string, len(v))
+		for key, val := range v {
+			out[key] = fmt.Sprint(val)
+		}
+		return out
+	}
+	return nil
+}
+
 // Error sends error response
 func (c *Context) Error(code int, msg string) {
 	http.Error(c.Writer, msg, code)
@@ -78,6 +217,8 @@ func (c *Context) View(name string, data interface{}, layout ...string) error {
 	payload := map[string]any{
 		"Flash":     c.Flash,
 		"FlashType": c.FlashType,
+		"Errors":    c.Errors,
+		"Old":       c.OldInput,
 	}
 
 	switch d := data.(type) {
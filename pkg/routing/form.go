@@ -0,0 +1,93 @@
+// pkg/routing/form.go
+package routing
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"mygola/pkg/gola"
+	"mygola/pkg/validation"
+)
+
+// Form wraps a handler that takes a form request. The request is bound,
+// prepared, authorized and validated before the handler runs:
+//
+//	router.Post("/posts", routing.Form(postController.Store))
+//
+//	func (c *PostController) Store(ctx *gola.Context, req *requests.StorePostRequest)
+//
+// Failed validation answers API requests with 422 and the errors as JSON,
+// and redirects other requests back with the errors and old input flashed.
+// Errors and old input are keyed by the name a field is bound under, its
+// `form` tag or else its JSON name.
+func Form[T any, PT interface {
+	*T
+	validation.FormRequest
+}](handler func(ctx *gola.Context, req PT)) func(ctx *gola.Context) {
+	return func(ctx *gola.Context) {
+		req := PT(new(T))
+
+		if err := ctx.Bind(req); err != nil {
+			formFailed(ctx, map[string][]string{"_request": {err.Error()}}, nil, http.StatusBadRequest)
+			return
+		}
+
+		req.Prepare()
+
+		if !req.Authorize(ctx) {
+			if ctx.WantsJSON() {
+				ctx.JSON(http.StatusForbidden, map[string]string{"message": "This action is unauthorized."})
+			} else {
+				ctx.Error(http.StatusForbidden, "This action is unauthorized.")
+			}
+			return
+		}
+
+		v, err := validation.ValidateRequest(ctx, req)
+		if v != nil && v.Err() != nil {
+			// Broken rules or an unreachable database are not the user's fault
+			log.Printf("validation error: %v", v.Err())
+			ctx.Error(http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		if err != nil {
+			formFailed(ctx, v.GetErrors(), v.Data, http.StatusUnprocessableEntity)
+			return
+		}
+
+		handler(ctx, req)
+	}
+}
+
+func formFailed(ctx *gola.Context, errors map[string][]string, data map[string]interface{}, status int) {
+	if ctx.WantsJSON() {
+		ctx.JSON(status, map[string]interface{}{
+			"message": "The given data was invalid.",
+			"errors":  errors,
+		})
+		return
+	}
+
+	ctx.FlashInput(errors, oldInput(data))
+	ctx.Back("/")
+}
+
+// oldInput keeps the scalar input for refilling the form, leaving out
+// uploads and passwords
+func oldInput(data map[string]interface{}) map[string]string {
+	old := make(map[string]string, len(data))
+	for key, value := range data {
+		if strings.Contains(strings.ToLower(key), "password") {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			old[key] = v
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			old[key] = fmt.Sprint(v)
+		}
+	}
+	return old
+}
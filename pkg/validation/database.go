@@ -0,0 +1,209 @@
+// pkg/validation/database.go
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Where is an extra condition of the unique and exists rules. Value may be
+// NULL or NOT_NULL, and a leading "!" negates it.
+type Where struct {
+	Column string
+	Value  string
+}
+
+// PresenceQuery describes the row lookup behind unique and exists
+type PresenceQuery struct {
+	Table        string
+	Column       string
+	Value        interface{}
+	IgnoreColumn string
+	IgnoreValue  interface{} // nil when nothing is ignored
+	Wheres       []Where
+}
+
+// PresenceVerifier counts rows matching a PresenceQuery. The application
+// sets one on its connection with SetPresenceVerifier; tests can swap in a
+// stub.
+type PresenceVerifier interface {
+	Count(q PresenceQuery) (int64, error)
+}
+
+var (
+	presence   PresenceVerifier
+	presenceMu sync.RWMutex
+
+	identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// SetPresenceVerifier replaces the verifier used by unique and exists
+func SetPresenceVerifier(verifier PresenceVerifier) {
+	presenceMu.Lock()
+	defer presenceMu.Unlock()
+	presence = verifier
+}
+
+func presenceVerifier() PresenceVerifier {
+	presenceMu.RLock()
+	defer presenceMu.RUnlock()
+	return presence
+}
+
+// GormPresenceVerifier runs lookups on a GORM connection
+type GormPresenceVerifier struct {
+	DB *gorm.DB
+}
+
+// NewGormPresenceVerifier creates a verifier on db
+func NewGormPresenceVerifier(db *gorm.DB) *GormPresenceVerifier {
+	return &GormPresenceVerifier{DB: db}
+}
+
+func (g *GormPresenceVerifier) Count(q PresenceQuery) (int64, error) {
+	if g.DB == nil {
+		return 0, errors.New("validation: no database connection for presence checks")
+	}
+
+	tx := g.DB.Table(q.Table).Where(clause.Eq{Column: clause.Column{Name: q.Column}, Value: q.Value})
+	if q.IgnoreValue != nil {
+		tx = tx.Where(clause.Neq{Column: clause.Column{Name: q.IgnoreColumn}, Value: q.IgnoreValue})
+	}
+	for _, w := range q.Wheres {
+		value, negate := w.Value, false
+		if strings.HasPrefix(value, "!") {
+			value, negate = value[1:], true
+		}
+
+		var expr clause.Expression
+		switch value {
+		case "NULL":
+			expr = clause.Eq{Column: clause.Column{Name: w.Column}, Value: nil}
+		case "NOT_NULL":
+			expr = clause.Neq{Column: clause.Column{Name: w.Column}, Value: nil}
+		default:
+			if negate {
+				expr = clause.Neq{Column: clause.Column{Name: w.Column}, Value: value}
+			} else {
+				expr = clause.Eq{Column: clause.Column{Name: w.Column}, Value: value}
+			}
+		}
+		tx = tx.Where(expr)
+	}
+
+	var count int64
+	err := tx.Count(&count).Error
+	return count, err
+}
+
+// presenceRule implements unique and exists:
+//
+//	unique:table,column,ignoreID,idColumn,whereColumn,whereValue...
+//	exists:table,column,whereColumn,whereValue...
+//
+// The column defaults to the field name. Pass NULL as ignoreID to skip it.
+type presenceRule struct {
+	unique  bool
+	message string
+}
+
+func (r *presenceRule) Passes(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	passed, _ := r.Check(field, value, params, data)
+	return passed
+}
+
+func (r *presenceRule) Message() string {
+	return r.message
+}
+
+// Check reports lookup failures separately, so the validator can expose
+// them through Err instead of treating them as invalid input
+func (r *presenceRule) Check(field string, value interface{}, params []string, data map[string]interface{}) (bool, error) {
+	q, err := r.query(field, value, params)
+	if err != nil {
+		return false, err
+	}
+
+	verifier := presenceVerifier()
+	if verifier == nil {
+		return false, fmt.Errorf("validation: %s rule needs a presence verifier, see SetPresenceVerifier", ruleName(r.unique))
+	}
+	count, err := verifier.Count(q)
+	if err != nil {
+		return false, fmt.Errorf("validation: %s lookup on %s failed: %v", ruleName(r.unique), q.Table, err)
+	}
+
+	if r.unique {
+		return count == 0, nil
+	}
+	return count > 0, nil
+}
+
+func (r *presenceRule) query(field string, value interface{}, params []string) (PresenceQuery, error) {
+	name := ruleName(r.unique)
+	if len(params) == 0 || params[0] == "" {
+		return PresenceQuery{}, fmt.Errorf("validation: %s rule needs a table", name)
+	}
+
+	q := PresenceQuery{
+		Table:  params[0],
+		Column: param(params, 1),
+		Value:  value,
+	}
+	if q.Column == "" {
+		// Nested keys such as "items.0.sku" look up the last segment
+		q.Column = field[strings.LastIndex(field, ".")+1:]
+	}
+
+	var rest []string
+	if len(params) > 2 {
+		rest = params[2:]
+	}
+	if r.unique && len(rest) > 0 {
+		if ignore := rest[0]; ignore != "" && ignore != "NULL" {
+			q.IgnoreValue = ignore
+			q.IgnoreColumn = "id"
+		}
+		if len(rest) > 1 && rest[1] != "" {
+			q.IgnoreColumn = rest[1]
+		}
+		rest = rest[min(2, len(rest)):]
+	}
+	for i := 0; i+1 < len(rest); i += 2 {
+		q.Wheres = append(q.Wheres, Where{Column: rest[i], Value: rest[i+1]})
+	}
+
+	// Table and column names come from rule strings and end up in SQL
+	identifiers := []string{q.Table, q.Column}
+	if q.IgnoreValue != nil {
+		identifiers = append(identifiers, q.IgnoreColumn)
+	}
+	for _, w := range q.Wheres {
+		identifiers = append(identifiers, w.Column)
+	}
+	for _, ident := range identifiers {
+		if !identifierRegex.MatchString(ident) {
+			return PresenceQuery{}, fmt.Errorf("validation: invalid identifier %q in %s rule", ident, name)
+		}
+	}
+
+	return q, nil
+}
+
+func ruleName(unique bool) string {
+	if unique {
+		return "unique"
+	}
+	return "exists"
+}
+
+func init() {
+	RegisterRule("unique", &presenceRule{unique: true, message: "The :attribute has already been taken"})
+	RegisterRule("exists", &presenceRule{message: "The selected :attribute is invalid"})
+}
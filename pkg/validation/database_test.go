@@ -0,0 +1,92 @@
+package validation
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func usePresenceDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, deleted_at TEXT)",
+		"INSERT INTO users (id, email) VALUES (1, 'ada@example.com')",
+		"INSERT INTO users (id, email, deleted_at) VALUES (2, 'gone@example.com', '2025-01-01')",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	SetPresenceVerifier(NewGormPresenceVerifier(db))
+	t.Cleanup(func() { SetPresenceVerifier(nil) })
+	return db
+}
+
+func TestPresenceRules(t *testing.T) {
+	usePresenceDB(t)
+
+	tests := []struct {
+		name  string
+		value string
+		rule  string
+		want  bool
+	}{
+		{"unique taken", "ada@example.com", "unique:users", false},
+		{"unique free", "new@example.com", "unique:users", true},
+		{"unique ignoring own row", "ada@example.com", "unique:users,email,1", true},
+		{"unique ignoring another row", "ada@example.com", "unique:users,email,2", false},
+		{"unique ignoring by column", "ada@example.com", "unique:users,email,ada@example.com,email", true},
+		{"unique with NULL ignore and where", "gone@example.com", "unique:users,email,NULL,id,deleted_at,NULL", true},
+		{"exists", "ada@example.com", "exists:users", true},
+		{"exists missing", "new@example.com", "exists:users,email", false},
+		{"exists with where NULL", "gone@example.com", "exists:users,email,deleted_at,NULL", false},
+		{"exists with where NOT_NULL", "gone@example.com", "exists:users,email,deleted_at,NOT_NULL", true},
+		{"exists with negated where", "ada@example.com", "exists:users,email,id,!1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(map[string]interface{}{"email": tt.value})
+			if got := v.Validate(map[string]string{"email": tt.rule}); got != tt.want {
+				t.Fatalf("%s on %q = %v, want %v (errors %v)", tt.rule, tt.value, got, tt.want, v.GetErrors())
+			}
+			if err := v.Err(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPresenceRuleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		db   bool
+	}{
+		{"no verifier", "unique:users", false},
+		{"missing table", "exists:users", true},
+		{"injected identifier", "unique:users,email;drop", true},
+		{"no table", "exists", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db {
+				db := usePresenceDB(t)
+				if tt.name == "missing table" {
+					db.Exec("DROP TABLE users")
+				}
+			}
+			v := NewValidator(map[string]interface{}{"email": "ada@example.com"})
+			if v.Validate(map[string]string{"email": tt.rule}) {
+				t.Fatal("validation passed")
+			}
+			if v.Err() == nil {
+				t.Fatal("the failed lookup was not reported by Err")
+			}
+		})
+	}
+}
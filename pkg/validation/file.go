@@ -0,0 +1,321 @@
+// pkg/validation/file.go
+package validation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// imageTypes are the sniffed content types the image rule accepts
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/bmp":  true,
+	"image/webp": true,
+}
+
+// mimeAliases covers extensions whose sniffed type differs from the type
+// registered for the extension, e.g. Office documents sniff as zip
+var mimeAliases = map[string][]string{
+	"jpg":  {"image/jpeg"},
+	"jpeg": {"image/jpeg"},
+	"txt":  {"text/plain"},
+	"csv":  {"text/plain", "text/csv"},
+	"json": {"text/plain", "application/json"},
+	"svg":  {"text/xml", "text/plain", "image/svg+xml"},
+	"docx": {"application/zip"},
+	"xlsx": {"application/zip"},
+	"pptx": {"application/zip"},
+	"zip":  {"application/zip", "application/x-zip-compressed"},
+}
+
+func init() {
+	builtins := map[string]*builtinRule{
+		"file":       {fn: validateFile, message: "The :attribute must be a file"},
+		"image":      {fn: validateImage, message: "The :attribute must be an image"},
+		"mimes":      {fn: validateMimes, message: "The :attribute must be a file of type: :values"},
+		"mimetypes":  {fn: validateMimeTypes, message: "The :attribute must be a file of type: :values"},
+		"max_size":   {fn: validateMaxSize, message: "The :attribute may not be greater than :max kilobytes", paramNames: []string{"max"}},
+		"dimensions": {fn: validateDimensions, message: "The :attribute has invalid image dimensions"},
+	}
+	for name, rule := range builtins {
+		RegisterRule(name, rule)
+	}
+}
+
+// fileValues returns the uploads of a field: one, or several when the field
+// was repeated. The file rules pass only when every upload passes.
+func fileValues(value interface{}) ([]*multipart.FileHeader, bool) {
+	var files []*multipart.FileHeader
+	switch v := value.(type) {
+	case *multipart.FileHeader:
+		files = []*multipart.FileHeader{v}
+	case []*multipart.FileHeader:
+		files = v
+	}
+	if len(files) == 0 {
+		return nil, false
+	}
+	for _, fh := range files {
+		if fh == nil {
+			return nil, false
+		}
+	}
+	return files, true
+}
+
+// eachFile runs check on every upload of a field
+func eachFile(value interface{}, check func(fh *multipart.FileHeader) bool) bool {
+	files, ok := fileValues(value)
+	if !ok {
+		return false
+	}
+	for _, fh := range files {
+		if !check(fh) {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffContentType detects the type from the first 512 bytes of the upload,
+// ignoring the client supplied Content-Type and file name
+func sniffContentType(fh *multipart.FileHeader) (string, bool) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	if n == 0 {
+		return "", false
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(buf[:n]), ";")
+	return strings.TrimSpace(contentType), true
+}
+
+func validateFile(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	_, ok := fileValues(value)
+	return ok
+}
+
+func validateImage(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return eachFile(value, func(fh *multipart.FileHeader) bool {
+		contentType, ok := sniffContentType(fh)
+		return ok && imageTypes[contentType]
+	})
+}
+
+// validateMimes checks the sniffed type against the types of the given
+// extensions: mimes:jpg,png,pdf
+func validateMimes(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return eachFile(value, func(fh *multipart.FileHeader) bool {
+		contentType, ok := sniffContentType(fh)
+		if !ok {
+			return false
+		}
+
+		for _, ext := range params {
+			ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+			for _, allowed := range extensionTypes(ext) {
+				if allowed == contentType {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+func extensionTypes(ext string) []string {
+	types := append([]string(nil), mimeAliases[ext]...)
+	if registered := mime.TypeByExtension("." + ext); registered != "" {
+		base, _, _ := strings.Cut(registered, ";")
+		types = append(types, strings.TrimSpace(base))
+	}
+	return types
+}
+
+// validateMimeTypes checks the sniffed type directly, "image/*" matches any
+// image: mimetypes:image/*,application/pdf
+func validateMimeTypes(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return eachFile(value, func(fh *multipart.FileHeader) bool {
+		contentType, ok := sniffContentType(fh)
+		if !ok {
+			return false
+		}
+
+		for _, allowed := range params {
+			if allowed == contentType {
+				return true
+			}
+			if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// validateMaxSize limits the size of each upload in kilobytes: max_size:2048
+func validateMaxSize(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	if len(params) == 0 {
+		return false
+	}
+	return eachFile(value, func(fh *multipart.FileHeader) bool {
+		return float64(fh.Size) <= parseFloat(params[0])*1024
+	})
+}
+
+// validateDimensions checks image dimensions without decoding the pixels:
+// dimensions:min_width=100,max_height=1000,ratio=16/9
+func validateDimensions(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return eachFile(value, func(fh *multipart.FileHeader) bool {
+		return checkDimensions(fh, params)
+	})
+}
+
+func checkDimensions(fh *multipart.FileHeader, params []string) bool {
+	f, err := fh.Open()
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	w, h, err := imageSize(f)
+	if err != nil {
+		return false
+	}
+	width, height := float64(w), float64(h)
+
+	for _, p := range params {
+		key, raw, _ := strings.Cut(p, "=")
+		if key == "ratio" {
+			ratio, ok := parseRatio(raw)
+			// Allow for rounding, 1920x1080 against 16/9
+			if !ok || height == 0 || abs(width/height-ratio) > 1.0/100 {
+				return false
+			}
+			continue
+		}
+
+		limit, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return false
+		}
+		var passed bool
+		switch key {
+		case "width":
+			passed = width == limit
+		case "height":
+			passed = height == limit
+		case "min_width":
+			passed = width >= limit
+		case "max_width":
+			passed = width <= limit
+		case "min_height":
+			passed = height >= limit
+		case "max_height":
+			passed = height <= limit
+		}
+		if !passed {
+			return false
+		}
+	}
+	return true
+}
+
+// imageSize reads the dimensions of every type the image rule accepts. The
+// standard library decodes GIF, JPEG and PNG headers; BMP and WebP, which
+// it has no decoders for, are read from their headers here.
+func imageSize(r io.Reader) (int, int, error) {
+	header := make([]byte, 30)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, 0, err
+	}
+	header = header[:n]
+
+	switch {
+	case len(header) >= 26 && string(header[:2]) == "BM":
+		return bmpSize(header)
+	case len(header) >= 16 && string(header[:4]) == "RIFF" && string(header[8:12]) == "WEBP":
+		return webpSize(header)
+	}
+
+	cfg, _, err := image.DecodeConfig(io.MultiReader(bytes.NewReader(header), r))
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// bmpSize reads the DIB header; a negative height marks a top-down bitmap
+func bmpSize(header []byte) (int, int, error) {
+	if binary.LittleEndian.Uint32(header[14:]) == 12 {
+		// OS/2 BITMAPCOREHEADER
+		return int(binary.LittleEndian.Uint16(header[18:])), int(binary.LittleEndian.Uint16(header[20:])), nil
+	}
+	width := int32(binary.LittleEndian.Uint32(header[18:]))
+	height := int32(binary.LittleEndian.Uint32(header[22:]))
+	if height < 0 {
+		height = -height
+	}
+	return int(width), int(height), nil
+}
+
+// webpSize reads the first chunk: lossy VP8, lossless VP8L or extended VP8X
+func webpSize(header []byte) (int, int, error) {
+	chunk := string(header[12:16])
+	switch {
+	case chunk == "VP8 " && len(header) >= 30:
+		return int(binary.LittleEndian.Uint16(header[26:]) & 0x3fff), int(binary.LittleEndian.Uint16(header[28:]) & 0x3fff), nil
+	case chunk == "VP8L" && len(header) >= 25:
+		bits := binary.LittleEndian.Uint32(header[21:])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case chunk == "VP8X" && len(header) >= 30:
+		width := int(header[24]) | int(header[25])<<8 | int(header[26])<<16
+		height := int(header[27]) | int(header[28])<<8 | int(header[29])<<16
+		return width + 1, height + 1, nil
+	}
+	return 0, 0, fmt.Errorf("validation: unsupported WebP chunk %q", chunk)
+}
+
+// parseRatio reads "16/9" or "1.5"
+func parseRatio(s string) (float64, bool) {
+	num, den, hasDen := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, false
+	}
+	if !hasDen {
+		return n, true
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0, false
+	}
+	return n / d, true
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,236 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"testing"
+)
+
+// upload builds a FileHeader the way a parsed multipart form holds it
+func upload(t *testing.T, filename string, content []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	w.Close()
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeGIF(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.Black}), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// bmpHeader is a BITMAPINFOHEADER bitmap; a negative height is top-down
+func bmpHeader(width, height int32) []byte {
+	b := make([]byte, 54)
+	copy(b, "BM")
+	binary.LittleEndian.PutUint32(b[2:], 54)
+	binary.LittleEndian.PutUint32(b[10:], 54)
+	binary.LittleEndian.PutUint32(b[14:], 40)
+	binary.LittleEndian.PutUint32(b[18:], uint32(width))
+	binary.LittleEndian.PutUint32(b[22:], uint32(height))
+	binary.LittleEndian.PutUint16(b[26:], 1)
+	binary.LittleEndian.PutUint16(b[28:], 24)
+	return b
+}
+
+// bmpCoreHeader is an OS/2 bitmap with 16-bit dimensions
+func bmpCoreHeader(width, height uint16) []byte {
+	b := make([]byte, 26)
+	copy(b, "BM")
+	binary.LittleEndian.PutUint32(b[10:], 26)
+	binary.LittleEndian.PutUint32(b[14:], 12)
+	binary.LittleEndian.PutUint16(b[18:], width)
+	binary.LittleEndian.PutUint16(b[20:], height)
+	binary.LittleEndian.PutUint16(b[22:], 1)
+	binary.LittleEndian.PutUint16(b[24:], 24)
+	return b
+}
+
+func webpHeader(chunk string, payload []byte) []byte {
+	b := []byte("RIFF\x00\x00\x00\x00WEBP" + chunk + "\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(b)-8+len(payload)))
+	binary.LittleEndian.PutUint32(b[16:], uint32(len(payload)))
+	return append(b, payload...)
+}
+
+// webpLossy is a VP8 key frame header
+func webpLossy(width, height uint16) []byte {
+	payload := []byte{0x30, 0x01, 0x00, 0x9d, 0x01, 0x2a, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(payload[6:], width)
+	binary.LittleEndian.PutUint16(payload[8:], height)
+	return webpHeader("VP8 ", payload)
+}
+
+// webpLossless stores width-1 and height-1 in 14 bits each
+func webpLossless(width, height uint32) []byte {
+	payload := []byte{0x2f, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(payload[1:], (width-1)|(height-1)<<14)
+	return webpHeader("VP8L", payload)
+}
+
+// webpExtended stores width-1 and height-1 in 24 bits each
+func webpExtended(width, height uint32) []byte {
+	payload := make([]byte, 10)
+	w, h := width-1, height-1
+	payload[4], payload[5], payload[6] = byte(w), byte(w>>8), byte(w>>16)
+	payload[7], payload[8], payload[9] = byte(h), byte(h>>8), byte(h>>16)
+	return webpHeader("VP8X", payload)
+}
+
+func TestImageSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       []byte
+		width, height int
+		wantErr       bool
+	}{
+		{"png", encodePNG(t, 160, 90), 160, 90, false},
+		{"jpeg", encodeJPEG(t, 33, 17), 33, 17, false},
+		{"gif", encodeGIF(t, 10, 20), 10, 20, false},
+		{"bmp", bmpHeader(640, 480), 640, 480, false},
+		{"top-down bmp", bmpHeader(640, -480), 640, 480, false},
+		{"os/2 bmp", bmpCoreHeader(320, 200), 320, 200, false},
+		{"lossy webp", webpLossy(1920, 1080), 1920, 1080, false},
+		{"lossy webp ignores the scale bits", webpLossy(1920|0xc000, 1080|0x4000), 1920, 1080, false},
+		{"lossless webp", webpLossless(16383, 1), 16383, 1, false},
+		{"extended webp", webpExtended(20000, 300), 20000, 300, false},
+		{"unknown webp chunk", webpHeader("ALPH", make([]byte, 10)), 0, 0, true},
+		{"truncated png", encodePNG(t, 1, 1)[:12], 0, 0, true},
+		{"not an image", []byte("%PDF-1.4 hello"), 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, err := imageSize(bytes.NewReader(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("imageSize error %v, want error %v", err, tt.wantErr)
+			}
+			if width != tt.width || height != tt.height {
+				t.Fatalf("imageSize = %dx%d, want %dx%d", width, height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestFileRules(t *testing.T) {
+	pngFile := upload(t, "photo.png", encodePNG(t, 160, 90))
+	jpegFile := upload(t, "photo.jpg", encodeJPEG(t, 100, 100))
+	bmpFile := upload(t, "scan.bmp", bmpHeader(1920, 1080))
+	webpFile := upload(t, "photo.webp", webpLossy(400, 300))
+	pdfFile := upload(t, "doc.pdf", []byte("%PDF-1.4\n%âãÏÓ\n1 0 obj\n"))
+	textAsPNG := upload(t, "evil.png", []byte("<?php system($_GET['c']); ?>"))
+	emptyFile := upload(t, "empty.png", nil)
+	big := upload(t, "big.txt", bytes.Repeat([]byte("a"), 3*1024))
+
+	tests := []struct {
+		name  string
+		rule  string
+		value interface{}
+		want  bool
+	}{
+		{"file", "file", pngFile, true},
+		{"file rejects strings", "file", "photo.png", false},
+		{"file rejects a nil upload", "file", []*multipart.FileHeader{pngFile, nil}, false},
+
+		{"image png", "image", pngFile, true},
+		{"image bmp", "image", bmpFile, true},
+		{"image webp", "image", webpFile, true},
+		{"image is sniffed, not named", "image", textAsPNG, false},
+		{"image rejects empty uploads", "image", emptyFile, false},
+		{"image rejects pdf", "image", pdfFile, false},
+		{"every upload must pass", "image", []*multipart.FileHeader{pngFile, pdfFile}, false},
+		{"several images", "image", []*multipart.FileHeader{pngFile, jpegFile}, true},
+
+		{"mimes by extension", "mimes:jpg,png", jpegFile, true},
+		{"mimes with a dot", "mimes:.pdf", pdfFile, true},
+		{"mimes ignores the file name", "mimes:png", textAsPNG, false},
+		{"mimes alias", "mimes:txt", big, true},
+		{"mimes mismatch", "mimes:pdf", pngFile, false},
+
+		{"mimetypes exact", "mimetypes:application/pdf", pdfFile, true},
+		{"mimetypes wildcard", "mimetypes:image/*", webpFile, true},
+		{"mimetypes wildcard mismatch", "mimetypes:image/*", pdfFile, false},
+
+		{"max_size under", "max_size:3", big, true},
+		{"max_size over", "max_size:2", big, false},
+		{"max_size fractions", "max_size:2.9", big, false},
+
+		{"dimensions min_width", "dimensions:min_width=100", pngFile, true},
+		{"dimensions max_height", "dimensions:max_height=80", pngFile, false},
+		{"dimensions exact", "dimensions:width=1920,height=1080", bmpFile, true},
+		{"dimensions ratio", "dimensions:ratio=16/9", pngFile, true},
+		{"dimensions decimal ratio", "dimensions:ratio=1.5", pngFile, false},
+		{"dimensions ratio of a bmp", "dimensions:ratio=16/9", bmpFile, true},
+		{"dimensions ratio of a webp", "dimensions:ratio=4/3", webpFile, true},
+		{"dimensions bad ratio", "dimensions:ratio=16/0", pngFile, false},
+		{"dimensions bad limit", "dimensions:min_width=wide", pngFile, false},
+		{"dimensions of a pdf", "dimensions:min_width=1", pdfFile, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(map[string]interface{}{"upload": tt.value})
+			if got := v.Validate(map[string]string{"upload": tt.rule}); got != tt.want {
+				t.Fatalf("%s = %v, want %v: %v", tt.rule, got, tt.want, v.Errors)
+			}
+		})
+	}
+}
+
+func TestFileMessages(t *testing.T) {
+	big := upload(t, "big.txt", bytes.Repeat([]byte("a"), 3*1024))
+	v := NewValidator(map[string]interface{}{"avatar": big})
+	v.Validate(map[string]string{"avatar": "image|mimes:jpg,png|max_size:2"})
+
+	want := []string{
+		"The avatar must be an image",
+		"The avatar must be a file of type: jpg, png",
+		"The avatar may not be greater than 2 kilobytes",
+	}
+	if got := v.Errors["avatar"]; len(got) != len(want) {
+		t.Fatalf("errors %q, want %q", got, want)
+	}
+	for i, message := range want {
+		if v.Errors["avatar"][i] != message {
+			t.Errorf("error %d = %q, want %q", i, v.Errors["avatar"][i], message)
+		}
+	}
+}
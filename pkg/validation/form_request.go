@@ -0,0 +1,48 @@
+// pkg/validation/form_request.go
+package validation
+
+import "mygola/pkg/gola"
+
+// FormRequest is a request struct that validates itself before the handler
+// runs, see routing.Form. Rules are merged with the struct's validate tags.
+type FormRequest interface {
+	// Rules returns rules keyed by the dotted field names, e.g. "items.0.qty"
+	Rules() map[string]string
+	// Messages overrides messages, keyed "rule" or "field.rule"
+	Messages() map[string]string
+	// Authorize decides whether the user may make this request at all
+	Authorize(ctx *gola.Context) bool
+	// Prepare normalizes the bound input before validation
+	Prepare()
+}
+
+// AttributeNamer is implemented by form requests with custom display names
+// for :attribute
+type AttributeNamer interface {
+	Attributes() map[string]string
+}
+
+// ValidateRequest validates a bound form request in the request's locale
+func ValidateRequest(ctx *gola.Context, req FormRequest) (*Validator, error) {
+	data, rules := FlattenStruct(req)
+	for field, rule := range req.Rules() {
+		if existing, ok := rules[field]; ok && existing != "" {
+			rule = existing + "|" + rule
+		}
+		rules[field] = rule
+	}
+
+	v := NewValidator(data).Locale(LocaleFromRequest(ctx.Request, ctx.Session))
+	v.SetMessages(req.Messages())
+	if named, ok := req.(AttributeNamer); ok {
+		v.SetAttributes(named.Attributes())
+	}
+
+	if !v.Validate(rules) {
+		if err := v.Err(); err != nil {
+			return v, err
+		}
+		return v, ErrValidationFailed
+	}
+	return v, nil
+}
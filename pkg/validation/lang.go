@@ -0,0 +1,205 @@
+// pkg/validation/lang.go
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"mygola/pkg/session"
+)
+
+// DefaultLocale is used when a request asks for nothing we have
+var DefaultLocale = "en"
+
+// LocaleSessionKey is the session key that overrides Accept-Language
+const LocaleSessionKey = "locale"
+
+var (
+	langPath   = filepath.Join("resources", "lang")
+	langCache  = make(map[string]*Messages)
+	langCacheM sync.Mutex
+)
+
+// Messages holds the translations of one locale, loaded from
+// resources/lang/{locale}/validation.yaml:
+//
+//	required: "The :attribute field is required"
+//	min:
+//	  string: "The :attribute must be at least :min characters"
+//	  numeric: "The :attribute must be at least :min"
+//	custom:
+//	  email:
+//	    required: "We need your email address"
+//	attributes:
+//	  email: "email address"
+type Messages struct {
+	Rules      map[string]map[string]string // rule -> value type -> message, "" is the plain message
+	Custom     map[string]map[string]string // field -> rule -> message
+	Attributes map[string]string
+}
+
+// SetLangPath changes the directory locales are loaded from and drops the
+// loaded ones
+func SetLangPath(dir string) {
+	langCacheM.Lock()
+	defer langCacheM.Unlock()
+	langPath = dir
+	langCache = make(map[string]*Messages)
+}
+
+// LoadMessages returns the messages of a locale, reading the YAML file once
+func LoadMessages(locale string) (*Messages, error) {
+	langCacheM.Lock()
+	defer langCacheM.Unlock()
+
+	if m, ok := langCache[locale]; ok {
+		return m, nil
+	}
+
+	file, err := os.ReadFile(filepath.Join(langPath, locale, "validation.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("validation: loading locale %s: %v", locale, err)
+	}
+	m, err := parseMessages(file)
+	if err != nil {
+		return nil, fmt.Errorf("validation: parsing locale %s: %v", locale, err)
+	}
+
+	langCache[locale] = m
+	return m, nil
+}
+
+func parseMessages(file []byte) (*Messages, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(file, &raw); err != nil {
+		return nil, err
+	}
+
+	m := &Messages{
+		Rules:      make(map[string]map[string]string),
+		Custom:     make(map[string]map[string]string),
+		Attributes: make(map[string]string),
+	}
+	for key, value := range raw {
+		switch key {
+		case "custom":
+			fields, _ := value.(map[string]interface{})
+			for field, rules := range fields {
+				m.Custom[field] = stringMap(rules)
+			}
+		case "attributes":
+			m.Attributes = stringMap(value)
+		default:
+			if str, ok := value.(string); ok {
+				m.Rules[key] = map[string]string{"": str}
+			} else {
+				m.Rules[key] = stringMap(value)
+			}
+		}
+	}
+	return m, nil
+}
+
+func stringMap(value interface{}) map[string]string {
+	out := make(map[string]string)
+	raw, _ := value.(map[string]interface{})
+	for k, v := range raw {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+// AvailableLocales lists the locales that have a validation.yaml
+func AvailableLocales() []string {
+	langCacheM.Lock()
+	dir := langPath
+	langCacheM.Unlock()
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "validation.yaml"))
+	locales := make([]string, 0, len(matches))
+	for _, match := range matches {
+		locales = append(locales, filepath.Base(filepath.Dir(match)))
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// LocaleFromRequest picks the locale for a request: the session's "locale"
+// value first, then the best Accept-Language match, then DefaultLocale. sess
+// may be nil.
+func LocaleFromRequest(r *http.Request, sess session.Session) string {
+	available := AvailableLocales()
+
+	if sess != nil {
+		if locale, ok := sess.Get(LocaleSessionKey).(string); ok {
+			if match := matchLocale(locale, available); match != "" {
+				return match
+			}
+		}
+	}
+
+	for _, tag := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if match := matchLocale(tag, available); match != "" {
+			return match
+		}
+	}
+	return DefaultLocale
+}
+
+// matchLocale finds tag among the available locales, trying the exact tag
+// ("bn-BD", "bn_BD") before the base language ("bn")
+func matchLocale(tag string, available []string) string {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	base, _, _ := strings.Cut(tag, "-")
+	for _, candidate := range []string{tag, base} {
+		for _, locale := range available {
+			if strings.EqualFold(strings.ReplaceAll(locale, "_", "-"), candidate) {
+				return locale
+			}
+		}
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns the language tags of the header ordered by
+// their q-value, dropping q=0
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.tag
+	}
+	return out
+}
@@ -0,0 +1,238 @@
+package validation
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testLocales = map[string]string{
+	"en": `
+required: "The :attribute field is required."
+min:
+  string: "The :attribute needs :min characters."
+  numeric: "The :attribute must be :min or more."
+attributes:
+  email: "email address"
+`,
+	"bn": `
+required: ":attribute ফিল্ডটি আবশ্যক"
+custom:
+  email:
+    required: "ইমেইল দিন"
+attributes:
+  name: "নাম"
+  items.*.qty: "পরিমাণ"
+`,
+	"pt_BR": `
+required: "O campo :attribute é obrigatório."
+`,
+}
+
+// useTestLocales points the loader at a fresh directory of locale files
+func useTestLocales(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	for locale, content := range testLocales {
+		if err := os.MkdirAll(filepath.Join(dir, locale), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, locale, "validation.yaml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	SetLangPath(dir)
+	t.Cleanup(func() { SetLangPath(filepath.Join("resources", "lang")) })
+}
+
+func TestParseMessages(t *testing.T) {
+	m, err := parseMessages([]byte(testLocales["en"] + "custom:\n  email:\n    required: \"We need it\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Messages{
+		Rules: map[string]map[string]string{
+			"required": {"": "The :attribute field is required."},
+			"min":      {"string": "The :attribute needs :min characters.", "numeric": "The :attribute must be :min or more."},
+		},
+		Custom:     map[string]map[string]string{"email": {"required": "We need it"}},
+		Attributes: map[string]string{"email": "email address"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("parseMessages = %+v, want %+v", m, want)
+	}
+
+	if _, err := parseMessages([]byte("required: [unclosed")); err == nil {
+		t.Fatal("parsed invalid YAML")
+	}
+}
+
+func TestLoadMessages(t *testing.T) {
+	useTestLocales(t)
+
+	m, err := LoadMessages("bn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := LoadMessages("bn"); again != m {
+		t.Fatal("the locale was read twice")
+	}
+	if _, err := LoadMessages("fr"); err == nil {
+		t.Fatal("loaded a missing locale")
+	}
+	if got := AvailableLocales(); !reflect.DeepEqual(got, []string{"bn", "en", "pt_BR"}) {
+		t.Fatalf("AvailableLocales = %v", got)
+	}
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	useTestLocales(t)
+
+	tests := []struct {
+		name     string
+		locale   string
+		data     map[string]interface{}
+		rules    map[string]string
+		messages map[string]string
+		attrs    map[string]string
+		want     map[string][]string
+	}{
+		{
+			name:   "locale rule message and attribute name",
+			locale: "bn",
+			data:   map[string]interface{}{},
+			rules:  map[string]string{"name": "required"},
+			want:   map[string][]string{"name": {"নাম ফিল্ডটি আবশ্যক"}},
+		},
+		{
+			name:   "field specific message of the locale",
+			locale: "bn",
+			data:   map[string]interface{}{},
+			rules:  map[string]string{"email": "required"},
+			want:   map[string][]string{"email": {"ইমেইল দিন"}},
+		},
+		{
+			name:   "wildcard attribute names",
+			locale: "bn",
+			data:   map[string]interface{}{"items.0.qty": ""},
+			rules:  map[string]string{"items.0.qty": "required"},
+			want:   map[string][]string{"items.0.qty": {"পরিমাণ ফিল্ডটি আবশ্যক"}},
+		},
+		{
+			name:   "missing messages fall back to the default locale",
+			locale: "bn",
+			data:   map[string]interface{}{"name": "ab"},
+			rules:  map[string]string{"name": "min:3"},
+			want:   map[string][]string{"name": {"The নাম needs 3 characters."}},
+		},
+		{
+			name:   "then to the built-in message",
+			locale: "bn",
+			data:   map[string]interface{}{"name": "x"},
+			rules:  map[string]string{"name": "numeric"},
+			want:   map[string][]string{"name": {"The নাম must be a number"}},
+		},
+		{
+			name:   "value type picks the variant",
+			locale: "en",
+			data:   map[string]interface{}{"age": "12"},
+			rules:  map[string]string{"age": "numeric|min:18"},
+			want:   map[string][]string{"age": {"The age must be 18 or more."}},
+		},
+		{
+			name:   "unknown locale uses the default",
+			locale: "fr",
+			data:   map[string]interface{}{},
+			rules:  map[string]string{"email": "required"},
+			want:   map[string][]string{"email": {"The email address field is required."}},
+		},
+		{
+			name:     "validator messages win over the locale",
+			locale:   "bn",
+			data:     map[string]interface{}{"items.3.qty": "0"},
+			rules:    map[string]string{"items.3.qty": "numeric|min:1", "email": "required"},
+			messages: map[string]string{"items.*.qty.min": "At least one :attribute", "required": "Fill in :attribute"},
+			attrs:    map[string]string{"items.3.qty": "quantity"},
+			want: map[string][]string{
+				"items.3.qty": {"At least one quantity"},
+				"email":       {"Fill in email address"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(tt.data).Locale(tt.locale).SetMessages(tt.messages).SetAttributes(tt.attrs)
+			v.Validate(tt.rules)
+			if !reflect.DeepEqual(v.Errors, tt.want) {
+				t.Fatalf("errors %v, want %v", v.Errors, tt.want)
+			}
+		})
+	}
+}
+
+type testSession map[string]interface{}
+
+func (s testSession) Get(key string) interface{}        { return s[key] }
+func (s testSession) Set(key string, value interface{}) { s[key] = value }
+func (s testSession) Delete(key string)                 { delete(s, key) }
+func (s testSession) Save() error                       { return nil }
+func (s testSession) ID() string                        { return "test" }
+
+func TestLocaleFromRequest(t *testing.T) {
+	useTestLocales(t)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		session        testSession
+		want           string
+	}{
+		{"no preference", "", nil, "en"},
+		{"exact match", "bn", nil, "bn"},
+		{"base language of a region", "bn-BD,en;q=0.8", nil, "bn"},
+		{"region with an underscore directory", "pt-BR", nil, "pt_BR"},
+		{"q-values order the tags", "en;q=0.5, bn;q=0.9", nil, "bn"},
+		{"q=0 is never picked", "bn;q=0, fr", nil, "en"},
+		{"unknown languages are skipped", "fr-CA, de, bn;q=0.1", nil, "bn"},
+		{"session wins", "bn", testSession{LocaleSessionKey: "en"}, "en"},
+		{"unknown session locale is ignored", "bn", testSession{LocaleSessionKey: "fr"}, "bn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			var got string
+			if tt.session == nil {
+				got = LocaleFromRequest(r, nil)
+			} else {
+				got = LocaleFromRequest(r, tt.session)
+			}
+			if got != tt.want {
+				t.Fatalf("LocaleFromRequest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"*", []string{}},
+		{"bn-BD, en;q=0.7, de;q=0.9", []string{"bn-BD", "de", "en"}},
+		{"en;q=bad, fr", []string{"fr"}},
+		{"en;q=0, fr;q=0.1", []string{"fr"}},
+	}
+	for _, tt := range tests {
+		if got := parseAcceptLanguage(tt.header); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("parseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
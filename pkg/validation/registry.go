@@ -0,0 +1,163 @@
+// pkg/validation/registry.go
+package validation
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Rule is a validation rule. params holds the comma separated values after
+// the colon ("between:1,10"), and data is the whole dataset so rules can
+// compare against other fields.
+type Rule interface {
+	Passes(field string, value interface{}, params []string, data map[string]interface{}) bool
+	Message() string
+}
+
+// ImplicitRule is a rule that still runs when the field is missing or
+// empty, like required
+type ImplicitRule interface {
+	Rule
+	Implicit() bool
+}
+
+// FallibleRule is a rule whose check can fail for reasons unrelated to the
+// data, such as an unreachable database. Such failures are reported through
+// Validator.Err and count as a failed rule.
+type FallibleRule interface {
+	Rule
+	Check(field string, value interface{}, params []string, data map[string]interface{}) (bool, error)
+}
+
+// RuleFunc is the function form of Rule.Passes
+type RuleFunc func(field string, value interface{}, params []string, data map[string]interface{}) bool
+
+type funcRule struct {
+	fn      RuleFunc
+	message string
+}
+
+func (r *funcRule) Passes(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return r.fn(field, value, params, data)
+}
+
+func (r *funcRule) Message() string {
+	return r.message
+}
+
+// Func wraps a closure as a Rule, for inline use with ValidateRules
+func Func(fn RuleFunc, message string) Rule {
+	return &funcRule{fn: fn, message: message}
+}
+
+var (
+	registry   = make(map[string]Rule)
+	registryMu sync.RWMutex
+)
+
+// Register adds a named rule usable in rule strings, e.g.
+//
+//	validation.Register("phone_bd", func(field string, value any, params []string, data map[string]any) bool {
+//		return phoneBD.MatchString(fmt.Sprint(value))
+//	}, "The :attribute must be a valid Bangladeshi phone number")
+//
+// Messages may use :attribute, :value, :values and :0, :1 ... for params.
+func Register(name string, fn RuleFunc, message string) {
+	RegisterRule(name, Func(fn, message))
+}
+
+// RegisterRule adds a named Rule implementation, replacing any rule of the
+// same name
+func RegisterRule(name string, rule Rule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = rule
+}
+
+func lookupRule(name string) (Rule, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	rule, ok := registry[name]
+	return rule, ok
+}
+
+// ruleEntry is one parsed rule of a field
+type ruleEntry struct {
+	name   string
+	params []string
+	rule   Rule
+}
+
+// parseRules turns a mix of rule strings and Rule values into entries.
+// Unknown names keep a nil rule so the validator can report them.
+func parseRules(rules []interface{}) []ruleEntry {
+	var entries []ruleEntry
+	for _, r := range rules {
+		switch rule := r.(type) {
+		case string:
+			for _, part := range splitRules(rule) {
+				entries = append(entries, parseRule(part))
+			}
+		case Rule:
+			entries = append(entries, ruleEntry{name: "custom", rule: rule})
+		}
+	}
+	return entries
+}
+
+func parseRule(part string) ruleEntry {
+	name, paramStr, hasParams := strings.Cut(part, ":")
+	entry := ruleEntry{name: name}
+	entry.rule, _ = lookupRule(name)
+
+	if hasParams {
+		if b, ok := entry.rule.(*builtinRule); ok && b.rawParams {
+			entry.params = []string{paramStr}
+		} else {
+			for _, p := range strings.Split(paramStr, ",") {
+				entry.params = append(entry.params, strings.TrimSpace(p))
+			}
+		}
+	}
+	return entry
+}
+
+// splitRules splits a rule string on "|". A regex pattern may itself contain
+// "|", so segments that do not start a known rule are glued back onto a
+// preceding regex rule.
+func splitRules(ruleStr string) []string {
+	var rules []string
+	for _, part := range strings.Split(ruleStr, "|") {
+		name, _, _ := strings.Cut(part, ":")
+		_, known := lookupRule(name)
+		if n := len(rules); n > 0 && !known && strings.HasPrefix(rules[n-1], "regex:") {
+			rules[n-1] += "|" + part
+			continue
+		}
+		if part != "" {
+			rules = append(rules, part)
+		}
+	}
+	return rules
+}
+
+// formatMessage fills the placeholders of a rule message
+func formatMessage(message, field string, value interface{}, entry ruleEntry) string {
+	replacements := []string{
+		":attribute", field,
+		":values", strings.Join(entry.params, ", "),
+		":value", stringValue(value),
+	}
+	if b, ok := entry.rule.(*builtinRule); ok {
+		for i, name := range b.paramNames {
+			if i < len(entry.params) {
+				replacements = append(replacements, ":"+name, entry.params[i])
+			}
+		}
+	}
+	for i, p := range entry.params {
+		replacements = append(replacements, ":"+strconv.Itoa(i), p)
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
@@ -0,0 +1,188 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// registerForTest adds a rule for the duration of the test
+func registerForTest(t *testing.T, name string, rule Rule) {
+	t.Helper()
+	RegisterRule(name, rule)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		delete(registry, name)
+	})
+}
+
+var phoneBD = regexp.MustCompile(`^01[3-9][0-9]{8}$`)
+
+func TestRegisteredRules(t *testing.T) {
+	registerForTest(t, "phone_bd", Func(func(field string, value interface{}, params []string, data map[string]interface{}) bool {
+		return phoneBD.MatchString(fmt.Sprint(value))
+	}, "The :attribute must be a valid Bangladeshi phone number"))
+	registerForTest(t, "divisible_by", Func(func(field string, value interface{}, params []string, data map[string]interface{}) bool {
+		n, ok := numericValue(value)
+		d := parseFloat(param(params, 0))
+		return ok && d != 0 && int(n)%int(d) == 0
+	}, "The :attribute (:value) must be divisible by :0"))
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		rules   string
+		wantErr string
+	}{
+		{"passes", "01712345678", "required|phone_bd", ""},
+		{"fails with its message", "12345", "required|phone_bd", "The phone must be a valid Bangladeshi phone number"},
+		{"params fill the placeholders", 10, "divisible_by:4", "The phone (10) must be divisible by 4"},
+		{"params are passed", 12, "divisible_by:4", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(map[string]interface{}{"phone": tt.value})
+			v.Validate(map[string]string{"phone": tt.rules})
+			if err := v.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(v.Errors["phone"], "; "); got != tt.wantErr {
+				t.Fatalf("errors %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterReplacesRules(t *testing.T) {
+	registerForTest(t, "even", Func(func(string, interface{}, []string, map[string]interface{}) bool { return false }, "first"))
+	Register("even", func(field string, value interface{}, params []string, data map[string]interface{}) bool {
+		n, _ := numericValue(value)
+		return int(n)%2 == 0
+	}, "The :attribute must be even")
+
+	v := NewValidator(map[string]interface{}{"n": 3})
+	v.Validate(map[string]string{"n": "even"})
+	if !reflect.DeepEqual(v.Errors["n"], []string{"The n must be even"}) {
+		t.Fatalf("got %v", v.Errors)
+	}
+}
+
+func TestInlineRules(t *testing.T) {
+	startsWithCode := Func(func(field string, value interface{}, params []string, data map[string]interface{}) bool {
+		return strings.HasPrefix(fmt.Sprint(value), fmt.Sprint(data["prefix"]))
+	}, "The :attribute must start with the prefix")
+
+	tests := []struct {
+		code       interface{}
+		wantErrors []string
+	}{
+		{"AB-1", nil},
+		{"XY-1", []string{"The code must start with the prefix"}},
+		{"", []string{"The code field is required"}},
+	}
+	for _, tt := range tests {
+		v := NewValidator(map[string]interface{}{"code": tt.code, "prefix": "AB"})
+		v.ValidateRules(map[string][]interface{}{
+			"code": {"required|bail", startsWithCode},
+		})
+		if !reflect.DeepEqual(v.Errors["code"], tt.wantErrors) {
+			t.Errorf("code %q: errors %v, want %v", tt.code, v.Errors["code"], tt.wantErrors)
+		}
+	}
+}
+
+// acceptedRule is implicit, so it runs when the field is missing
+type acceptedRule struct{}
+
+func (acceptedRule) Passes(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return value == true || value == "yes"
+}
+func (acceptedRule) Message() string { return "The :attribute must be accepted" }
+func (acceptedRule) Implicit() bool  { return true }
+
+// remoteRule fails for reasons that have nothing to do with the data
+type remoteRule struct{ err error }
+
+func (r remoteRule) Passes(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	passed, _ := r.Check(field, value, params, data)
+	return passed
+}
+func (r remoteRule) Message() string { return "The :attribute could not be verified" }
+func (r remoteRule) Check(field string, value interface{}, params []string, data map[string]interface{}) (bool, error) {
+	return r.err == nil, r.err
+}
+
+func TestRuleInterfaces(t *testing.T) {
+	unavailable := errors.New("service unavailable")
+	registerForTest(t, "accepted", acceptedRule{})
+	registerForTest(t, "verified", remoteRule{})
+	registerForTest(t, "verified_offline", remoteRule{err: unavailable})
+
+	tests := []struct {
+		name       string
+		data       map[string]interface{}
+		rules      string
+		wantErrors int
+		wantErr    error
+	}{
+		{"implicit rule runs on a missing field", map[string]interface{}{}, "accepted", 1, nil},
+		{"implicit rule passes", map[string]interface{}{"field": "yes"}, "accepted", 0, nil},
+		{"fallible rule passes", map[string]interface{}{"field": "x"}, "verified", 0, nil},
+		{"fallible rule error reaches Err", map[string]interface{}{"field": "x"}, "verified_offline", 1, unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(tt.data)
+			v.Validate(map[string]string{"field": tt.rules})
+			if got := len(v.Errors["field"]); got != tt.wantErrors {
+				t.Fatalf("%d errors, want %d: %v", got, tt.wantErrors, v.Errors)
+			}
+			if err := v.Err(); err != tt.wantErr {
+				t.Fatalf("Err() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitRules(t *testing.T) {
+	tests := []struct {
+		rules string
+		want  []string
+	}{
+		{"required|max:5", []string{"required", "max:5"}},
+		{"required||email", []string{"required", "email"}},
+		{"regex:^(a|b)$|max:5", []string{"regex:^(a|b)$", "max:5"}},
+		{"regex:/^(a|b|c)$/", []string{"regex:/^(a|b|c)$/"}},
+		{"regex:^a$|unknown_rule", []string{"regex:^a$|unknown_rule"}},
+	}
+	for _, tt := range tests {
+		if got := splitRules(tt.rules); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitRules(%q) = %q, want %q", tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule       string
+		wantName   string
+		wantParams []string
+		wantKnown  bool
+	}{
+		{"between: 1, 10", "between", []string{"1", "10"}, true},
+		{"regex:^[a,b]+$", "regex", []string{"^[a,b]+$"}, true},
+		{"date_format:Jan 2, 2006", "date_format", []string{"Jan 2, 2006"}, true},
+		{"required", "required", nil, true},
+		{"nope:1", "nope", []string{"1"}, false},
+	}
+	for _, tt := range tests {
+		entry := parseRule(tt.rule)
+		if entry.name != tt.wantName || !reflect.DeepEqual(entry.params, tt.wantParams) || (entry.rule != nil) != tt.wantKnown {
+			t.Errorf("parseRule(%q) = %q %q known=%v", tt.rule, entry.name, entry.params, entry.rule != nil)
+		}
+	}
+}
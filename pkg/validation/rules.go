@@ -0,0 +1,458 @@
+// pkg/validation/rules.go
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// builtinRule is how the bundled rules are registered
+type builtinRule struct {
+	fn         RuleFunc
+	message    string
+	messages   map[string]string // per value type: string, numeric, array
+	paramNames []string          // names for message placeholders, e.g. :min
+	implicit   bool              // runs on missing and empty fields
+	flag       bool              // modifier only, never fails (nullable, bail...)
+	rawParams  bool              // the parameter is not split on commas
+}
+
+func (r *builtinRule) Passes(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return r.fn(field, value, params, data)
+}
+
+func (r *builtinRule) Message() string {
+	return r.message
+}
+
+func (r *builtinRule) Implicit() bool {
+	return r.implicit
+}
+
+var (
+	emailRegex = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
+	uuidRegex  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// dateLayouts are tried in order by the date rules
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func sizeMessages(verb string) map[string]string {
+	return map[string]string{
+		"string":  "The :attribute " + verb + " characters",
+		"numeric": "The :attribute " + verb,
+		"array":   "The :attribute " + verb + " items",
+	}
+}
+
+func init() {
+	builtins := map[string]*builtinRule{
+		"required":  {fn: validateRequired, message: "The :attribute field is required", implicit: true},
+		"nullable":  {flag: true},
+		"sometimes": {flag: true},
+		"bail":      {flag: true},
+
+		"email":   {fn: validateEmail, message: "The :attribute must be a valid email address"},
+		"numeric": {fn: validateNumeric, message: "The :attribute must be a number"},
+		"integer": {fn: validateInteger, message: "The :attribute must be an integer"},
+		"boolean": {fn: validateBoolean, message: "The :attribute field must be true or false"},
+		"json":    {fn: validateJSON, message: "The :attribute must be a valid JSON string"},
+		"array":   {fn: validateArray, message: "The :attribute must be an array"},
+
+		"min":     {fn: validateMin, message: "The :attribute must be at least :min", messages: sizeMessages("must be at least :min"), paramNames: []string{"min"}},
+		"max":     {fn: validateMax, message: "The :attribute may not be greater than :max", messages: sizeMessages("may not be greater than :max"), paramNames: []string{"max"}},
+		"size":    {fn: validateSize, message: "The :attribute must be :size", messages: sizeMessages("must be :size"), paramNames: []string{"size"}},
+		"between": {fn: validateBetween, message: "The :attribute must be between :min and :max", messages: sizeMessages("must be between :min and :max"), paramNames: []string{"min", "max"}},
+
+		"in":          {fn: validateIn, message: "The selected :attribute is invalid"},
+		"not_in":      {fn: not(validateIn), message: "The selected :attribute is invalid"},
+		"regex":       {fn: validateRegex, message: "The :attribute format is invalid", rawParams: true},
+		"alpha":       {fn: validateAlpha, message: "The :attribute may only contain letters"},
+		"alpha_num":   {fn: validateAlphaNum, message: "The :attribute may only contain letters and numbers"},
+		"alpha_dash":  {fn: validateAlphaDash, message: "The :attribute may only contain letters, numbers, dashes and underscores"},
+		"starts_with": {fn: validatePrefix, message: "The :attribute must start with one of the following: :values"},
+		"ends_with":   {fn: validateSuffix, message: "The :attribute must end with one of the following: :values"},
+		"url":         {fn: validateURL, message: "The :attribute must be a valid URL"},
+		"ip":          {fn: validateIP(""), message: "The :attribute must be a valid IP address"},
+		"ipv4":        {fn: validateIP("ipv4"), message: "The :attribute must be a valid IPv4 address"},
+		"ipv6":        {fn: validateIP("ipv6"), message: "The :attribute must be a valid IPv6 address"},
+		"uuid":        {fn: validateUUID, message: "The :attribute must be a valid UUID"},
+
+		"date":            {fn: validateDate, message: "The :attribute is not a valid date"},
+		"date_format":     {fn: validateDateFormat, message: "The :attribute does not match the format :format", paramNames: []string{"format"}, rawParams: true},
+		"before":          {fn: validateDateCompare("before"), message: "The :attribute must be a date before :date", paramNames: []string{"date"}},
+		"after":           {fn: validateDateCompare("after"), message: "The :attribute must be a date after :date", paramNames: []string{"date"}},
+		"before_or_equal": {fn: validateDateCompare("before_or_equal"), message: "The :attribute must be a date before or equal to :date", paramNames: []string{"date"}},
+		"after_or_equal":  {fn: validateDateCompare("after_or_equal"), message: "The :attribute must be a date after or equal to :date", paramNames: []string{"date"}},
+
+		"confirmed": {fn: validateConfirmed, message: "The :attribute confirmation does not match"},
+		"same":      {fn: validateSame, message: "The :attribute and :other must match", paramNames: []string{"other"}},
+		"different": {fn: not(validateSame), message: "The :attribute and :other must be different", paramNames: []string{"other"}},
+	}
+
+	for name, rule := range builtins {
+		if rule.flag {
+			rule.fn = func(string, interface{}, []string, map[string]interface{}) bool { return true }
+		}
+		RegisterRule(name, rule)
+	}
+}
+
+func not(fn RuleFunc) RuleFunc {
+	return func(field string, value interface{}, params []string, data map[string]interface{}) bool {
+		return !fn(field, value, params, data)
+	}
+}
+
+func param(params []string, i int) string {
+	if i < len(params) {
+		return params[i]
+	}
+	return ""
+}
+
+func validateRequired(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	if str, ok := value.(string); ok {
+		return strings.TrimSpace(str) != ""
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() > 0
+	}
+
+	return true
+}
+
+func validateEmail(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	str, ok := value.(string)
+	return ok && emailRegex.MatchString(str)
+}
+
+func validateNumeric(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	if _, ok := numericValue(value); ok {
+		return true
+	}
+	if str, ok := value.(string); ok {
+		_, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		return err == nil
+	}
+	return false
+}
+
+func validateInteger(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	switch val := value.(type) {
+	case string:
+		_, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		return err == nil
+	case float32, float64:
+		// JSON numbers arrive as float64
+		num, _ := numericValue(val)
+		return num == float64(int64(num))
+	}
+	_, ok := numericValue(value)
+	return ok
+}
+
+func validateBoolean(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	switch val := value.(type) {
+	case bool:
+		return true
+	case string:
+		switch val {
+		case "true", "false", "1", "0":
+			return true
+		}
+		return false
+	}
+	if num, ok := numericValue(value); ok {
+		return num == 0 || num == 1
+	}
+	return false
+}
+
+func validateJSON(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	switch val := value.(type) {
+	case string:
+		return json.Valid([]byte(val))
+	case []byte:
+		return json.Valid(val)
+	}
+	return false
+}
+
+func validateArray(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	_, ok := lengthOf(value)
+	return ok
+}
+
+func validateMin(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	size, ok := sizeOf(value)
+	return ok && size >= parseFloat(param(params, 0))
+}
+
+func validateMax(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	size, ok := sizeOf(value)
+	return ok && size <= parseFloat(param(params, 0))
+}
+
+func validateSize(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	size, ok := sizeOf(value)
+	return ok && size == parseFloat(param(params, 0))
+}
+
+func validateBetween(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	if len(params) < 2 {
+		return false
+	}
+	size, ok := sizeOf(value)
+	return ok && size >= parseFloat(params[0]) && size <= parseFloat(params[1])
+}
+
+func validateIn(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	str := stringValue(value)
+	for _, option := range params {
+		if str == option {
+			return true
+		}
+	}
+	return false
+}
+
+func validateRegex(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	pattern := param(params, 0)
+	// Accept both "regex:^a+$" and the delimited "regex:/^a+$/" form
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		pattern = pattern[1 : len(pattern)-1]
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(stringValue(value))
+}
+
+func validateAlpha(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return matchRunes(value, isLetter)
+}
+
+func validateAlphaNum(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return matchRunes(value, func(r rune) bool {
+		return isLetter(r) || unicode.IsNumber(r)
+	})
+}
+
+func validateAlphaDash(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return matchRunes(value, func(r rune) bool {
+		return isLetter(r) || unicode.IsNumber(r) || r == '-' || r == '_'
+	})
+}
+
+// isLetter also accepts combining marks, which Bengali and other scripts
+// write their vowel signs with
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r)
+}
+
+func validatePrefix(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	for _, prefix := range params {
+		if strings.HasPrefix(str, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSuffix(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	for _, suffix := range params {
+		if strings.HasSuffix(str, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateURL(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	u, err := url.ParseRequestURI(str)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func validateIP(version string) RuleFunc {
+	return func(field string, value interface{}, params []string, data map[string]interface{}) bool {
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		ip := net.ParseIP(str)
+		if ip == nil {
+			return false
+		}
+		switch version {
+		case "ipv4":
+			return ip.To4() != nil
+		case "ipv6":
+			return ip.To4() == nil
+		}
+		return true
+	}
+}
+
+func validateUUID(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	str, ok := value.(string)
+	return ok && uuidRegex.MatchString(str)
+}
+
+func validateDate(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	_, ok := parseDate(value)
+	return ok
+}
+
+// validateDateFormat takes a Go layout, e.g. date_format:2006-01-02
+func validateDateFormat(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(param(params, 0), str)
+	return err == nil
+}
+
+// validateDateCompare handles before/after. The parameter is a date, the
+// name of another field, or one of today, tomorrow, yesterday and now.
+func validateDateCompare(ruleName string) RuleFunc {
+	return func(field string, value interface{}, params []string, data map[string]interface{}) bool {
+		date, ok := parseDate(value)
+		if !ok {
+			return false
+		}
+
+		other, ok := resolveDate(param(params, 0), data)
+		if !ok {
+			return false
+		}
+
+		switch ruleName {
+		case "before":
+			return date.Before(other)
+		case "after":
+			return date.After(other)
+		case "before_or_equal":
+			return !date.After(other)
+		case "after_or_equal":
+			return !date.Before(other)
+		}
+		return false
+	}
+}
+
+func resolveDate(param string, data map[string]interface{}) (time.Time, bool) {
+	// Days start at local midnight, like the dates parsed without a zone
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch param {
+	case "now":
+		return now, true
+	case "today":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	}
+
+	if other, exists := data[param]; exists {
+		return parseDate(other)
+	}
+	return parseDate(param)
+}
+
+func validateConfirmed(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	return validateSame(field, value, []string{field + "_confirmation"}, data)
+}
+
+func validateSame(field string, value interface{}, params []string, data map[string]interface{}) bool {
+	otherValue, exists := data[param(params, 0)]
+	if !exists {
+		return false
+	}
+	return stringValue(value) == stringValue(otherValue)
+}
+
+func matchRunes(value interface{}, allowed func(r rune) bool) bool {
+	str, ok := value.(string)
+	if !ok || str == "" {
+		return false
+	}
+	for _, r := range str {
+		if !allowed(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseDate(value interface{}) (time.Time, bool) {
+	switch val := value.(type) {
+	case time.Time:
+		return val, !val.IsZero()
+	case *time.Time:
+		if val == nil {
+			return time.Time{}, false
+		}
+		return *val, !val.IsZero()
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(val), time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// stringValue renders scalars for comparisons such as in and same
+func stringValue(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,163 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuiltinRules(t *testing.T) {
+	tests := []struct {
+		rule  string
+		value interface{}
+		want  bool
+	}{
+		{"required", "ada", true},
+		{"required", "  ", false},
+		{"required", nil, false},
+		{"required", []string{}, false},
+		{"required", 0, true},
+		{"email", "ada@example.com", true},
+		{"email", "ada@", false},
+		{"numeric", "12.5", true},
+		{"numeric", "twelve", false},
+		{"integer", "12", true},
+		{"integer", 12.0, true},
+		{"integer", 12.5, false},
+		{"boolean", "1", true},
+		{"boolean", "yes", false},
+		{"json", `{"a":1}`, true},
+		{"json", `{a:1}`, false},
+		{"array", []int{1}, true},
+		{"array", "1", false},
+
+		{"min:3", "abc", true},
+		{"min:3", "ab", false},
+		{"min:3", "ৎৎৎ", true}, // characters, not bytes
+		{"max:3", []int{1, 2, 3, 4}, false},
+		{"numeric|min:10", "9", false},
+		{"numeric|max:10", "10", true},
+		{"integer|size:5", 5, true},
+		{"size:2", map[string]int{"a": 1, "b": 2}, true},
+		{"between:2,4", "abcde", false},
+		{"numeric|between:2,4", 3.5, true},
+
+		{"in:draft,published", "draft", true},
+		{"in:draft,published", "archived", false},
+		{"in:1,2", 2.0, true},
+		{"not_in:admin,root", "admin", false},
+		{"regex:^[a-z]+$", "abc", true},
+		{"regex:/^(yes|no)$/", "no", true},
+		{"regex:/^(yes|no)$/", "maybe", false},
+		{"alpha", "আমার", true},
+		{"alpha", "abc1", false},
+		{"alpha_num", "abc1", true},
+		{"alpha_dash", "my-post_1", true},
+		{"alpha_dash", "my post", false},
+		{"starts_with:http,ftp", "ftp://host", true},
+		{"ends_with:.jpg,.png", "photo.gif", false},
+		{"url", "https://example.com/a?b=c", true},
+		{"url", "example.com", false},
+		{"ip", "::1", true},
+		{"ipv4", "::1", false},
+		{"ipv6", "10.0.0.1", false},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid", "123e4567", false},
+
+		{"date", "2025-02-28", true},
+		{"date", "2025-02-30", false},
+		{"date", time.Now(), true},
+		{"date_format:02/01/2006", "28/02/2025", true},
+		{"date_format:02/01/2006", "2025-02-28", false},
+		{"before:2025-01-01", "2024-12-31", true},
+		{"before:2025-01-01", "2025-01-01", false},
+		{"before_or_equal:2025-01-01", "2025-01-01", true},
+		{"after:today", time.Now().AddDate(0, 0, 1).Format("2006-01-02"), true},
+		{"after:tomorrow", time.Now().Format("2006-01-02"), false},
+		{"after_or_equal:yesterday", time.Now().Format("2006-01-02 15:04:05"), true},
+		{"after:starts_at", "2025-06-02", true},
+		{"after:starts_at", "2025-05-31", false},
+		{"before:starts_at", "not a date", false},
+
+		{"confirmed", "secret", true},
+		{"same:password", "secret", true},
+		{"different:password", "secret", false},
+		{"same:missing", "secret", false},
+	}
+
+	for _, tt := range tests {
+		data := map[string]interface{}{
+			"field":              tt.value,
+			"field_confirmation": "secret",
+			"password":           "secret",
+			"starts_at":          "2025-06-01",
+		}
+		v := NewValidator(data)
+		if got := v.Validate(map[string]string{"field": tt.rule}); got != tt.want {
+			t.Errorf("%s on %#v = %v, want %v (%v)", tt.rule, tt.value, got, tt.want, v.Errors["field"])
+		}
+		if err := v.Err(); err != nil {
+			t.Errorf("%s: %v", tt.rule, err)
+		}
+	}
+}
+
+func TestModifierRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       map[string]interface{}
+		rules      string
+		wantErrors int
+	}{
+		{"optional field is skipped when missing", map[string]interface{}{}, "email|max:5", 0},
+		{"empty string skips non-implicit rules", map[string]interface{}{"field": ""}, "email", 0},
+		{"nullable allows nil", map[string]interface{}{"field": nil}, "nullable|numeric", 0},
+		{"sometimes skips a missing field", map[string]interface{}{}, "sometimes|required", 0},
+		{"sometimes checks a present field", map[string]interface{}{"field": ""}, "sometimes|required", 1},
+		{"every failing rule is reported", map[string]interface{}{"field": "a!"}, "alpha|min:3", 2},
+		{"bail stops at the first failure", map[string]interface{}{"field": "a!"}, "bail|alpha|min:3", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(tt.data)
+			v.Validate(map[string]string{"field": tt.rules})
+			if got := len(v.Errors["field"]); got != tt.wantErrors {
+				t.Fatalf("%d errors, want %d: %v", got, tt.wantErrors, v.Errors)
+			}
+		})
+	}
+}
+
+func TestBuiltinMessages(t *testing.T) {
+	tests := []struct {
+		rule  string
+		value interface{}
+		want  string
+	}{
+		{"required", "", "The title field is required"},
+		{"min:5", "abc", "The title must be at least 5 characters"},
+		{"numeric|min:5", "3", "The title must be at least 5"},
+		{"max:1", []string{"a", "b"}, "The title may not be greater than 1 items"},
+		{"between:2,4", "a", "The title must be between 2 and 4 characters"},
+		{"starts_with:a,b", "c", "The title must start with one of the following: a, b"},
+		{"date_format:2006-01-02", "x", "The title does not match the format 2006-01-02"},
+		{"same:slug", "x", "The title and slug must match"},
+	}
+	for _, tt := range tests {
+		v := NewValidator(map[string]interface{}{"title": tt.value, "slug": "y"})
+		v.Validate(map[string]string{"title": tt.rule})
+		if errs := v.Errors["title"]; len(errs) != 1 || errs[0] != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.rule, errs, tt.want)
+		}
+	}
+}
+
+func TestUnknownRulesAreReported(t *testing.T) {
+	v := NewValidator(map[string]interface{}{"title": "Hello"})
+	if v.Validate(map[string]string{"title": "required|maxx:5"}) {
+		t.Fatal("a misspelled rule passed validation")
+	}
+	if err := v.Err(); !errors.Is(err, ErrUnknownRule) {
+		t.Fatalf("Err() = %v, want ErrUnknownRule", err)
+	}
+}
@@ -0,0 +1,209 @@
+// pkg/validation/struct.go
+package validation
+
+import (
+	"fmt"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Struct validates a struct using its `validate` tags, for example
+//
+//	type StorePostRequest struct {
+//		Title string `json:"title" validate:"required|max:255"`
+//		Items []Item `json:"items" validate:"required"`
+//		Tags  []string `json:"tags" each:"max:20"`
+//	}
+//
+// Nested structs, slices and maps are walked and reported with dotted keys
+// built from the names the fields are bound under, e.g. "items.0.qty". Rules for the elements of a
+// slice or map of scalars go in the `each` tag.
+func Struct(s interface{}) (*Validator, error) {
+	validator := NewValidator(nil)
+	return validator, validator.ValidateStruct(s)
+}
+
+// ValidateStruct is Struct on a prepared validator, so the locale, messages
+// and attributes can be set first:
+//
+//	v := validation.NewValidator(nil).Locale(locale)
+//	err := v.ValidateStruct(req)
+func (v *Validator) ValidateStruct(s interface{}) error {
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("validation: Struct expects a struct, got nil %T", s)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validation: Struct expects a struct, got %T", s)
+	}
+
+	data := make(map[string]interface{})
+	rules := make(map[string]string)
+	walkStruct(rv, "", data, rules)
+
+	v.Data = data
+	if !v.Validate(rules) {
+		// A misconfigured rule is a programming error, not a failed request
+		if err := v.Err(); err != nil {
+			return err
+		}
+		return ErrValidationFailed
+	}
+	return nil
+}
+
+// FlattenStruct returns the dotted-key data and rules Struct validates with
+func FlattenStruct(s interface{}) (map[string]interface{}, map[string]string) {
+	data := make(map[string]interface{})
+	rules := make(map[string]string)
+
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		walkStruct(rv, "", data, rules)
+	}
+	return data, rules
+}
+
+func walkStruct(rv reflect.Value, prefix string, data map[string]interface{}, rules map[string]string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		// Like encoding/json, the fields of an embedded unexported struct
+		// type are still promoted
+		embeddedStruct := field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct
+		if !field.IsExported() && !embeddedStruct {
+			continue
+		}
+
+		name, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		// Embedded structs without a name share the parent's keys
+		if embeddedStruct && field.Tag.Get("form") == "" && field.Tag.Get("json") == "" {
+			fv := rv.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			walkStruct(fv, prefix, data, rules)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		key := joinKey(prefix, name)
+		if tag := field.Tag.Get("validate"); tag != "" {
+			rules[key] = tag
+		}
+		walkValue(rv.Field(i), key, field.Tag.Get("each"), data, rules)
+	}
+}
+
+var fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+
+func walkValue(fv reflect.Value, key, eachRules string, data map[string]interface{}, rules map[string]string) {
+	// Uploads are validated as a whole by the file rules
+	if fv.Type() == fileHeaderType {
+		if !fv.IsNil() {
+			data[key] = fv.Interface()
+		}
+		return
+	}
+
+	// A nil pointer is treated as a missing field, and its children are not
+	// validated at all
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return
+		}
+		fv = fv.Elem()
+	}
+
+	data[key] = fv.Interface()
+	if isLeaf(fv.Type()) {
+		return
+	}
+
+	switch fv.Kind() {
+	case reflect.Struct:
+		walkStruct(fv, key, data, rules)
+	case reflect.Slice, reflect.Array:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < fv.Len(); i++ {
+			walkElement(fv.Index(i), joinKey(key, strconv.Itoa(i)), eachRules, data, rules)
+		}
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, k := range fv.MapKeys() {
+			walkElement(fv.MapIndex(k), joinKey(key, k.String()), eachRules, data, rules)
+		}
+	}
+}
+
+func walkElement(ev reflect.Value, key, eachRules string, data map[string]interface{}, rules map[string]string) {
+	if eachRules != "" {
+		rules[key] = eachRules
+	}
+	walkValue(ev, key, "", data, rules)
+}
+
+// isLeaf reports types that are validated as a whole rather than walked
+func isLeaf(t reflect.Type) bool {
+	if t == reflect.TypeOf(time.Time{}) || t == fileHeaderType.Elem() {
+		return true
+	}
+	switch indirectType(t).Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return false
+	}
+	return true
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldName returns the name a field is bound under, the same one
+// gola.Bind uses: the `form` tag, then the JSON name, then the Go name
+func fieldName(field reflect.StructField) (string, bool) {
+	for _, key := range []string{"form", "json"} {
+		if tag := field.Tag.Get(key); tag != "" {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				return "", true
+			}
+			if name != "" {
+				return name, false
+			}
+		}
+	}
+	return field.Name, false
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"errors"
+	"mime/multipart"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"alpha_num|size:4"`
+}
+
+type testItem struct {
+	SKU string `form:"sku" json:"product_sku" validate:"required|alpha_dash"`
+	Qty int    `json:"qty" validate:"integer|min:1"`
+}
+
+type testTimestamps struct {
+	PublishAt *time.Time `json:"publish_at" validate:"nullable|after:2025-01-01"`
+}
+
+type testOrder struct {
+	testTimestamps
+	Email    string                `json:"email" validate:"required|email"`
+	Name     string                `validate:"required|max:10"`
+	Secret   string                `json:"-" validate:"required"`
+	Address  *testAddress          `json:"address" validate:"required"`
+	Items    []testItem            `json:"items" validate:"required|min:1"`
+	Tags     []string              `json:"tags" each:"alpha|max:5"`
+	Meta     map[string]string     `json:"meta" each:"max:3"`
+	Created  time.Time             `json:"created" validate:"required"`
+	Avatar   *multipart.FileHeader `form:"avatar" validate:"file"`
+	internal string
+}
+
+func validOrder() testOrder {
+	publishAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	return testOrder{
+		testTimestamps: testTimestamps{PublishAt: &publishAt},
+		Email:          "ada@example.com",
+		Name:           "Ada",
+		Address:        &testAddress{City: "Dhaka", Zip: "1207"},
+		Items:          []testItem{{SKU: "tea-1", Qty: 2}},
+		Tags:           []string{"hot"},
+		Meta:           map[string]string{"ref": "x"},
+		Created:        time.Now(),
+	}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(o *testOrder)
+		wantFields []string
+	}{
+		{"valid", func(o *testOrder) {}, nil},
+		{"json names", func(o *testOrder) { o.Email = "ada" }, []string{"email"}},
+		{"go name without tags", func(o *testOrder) { o.Name = "Ada Lovelace" }, []string{"Name"}},
+		{"nested struct", func(o *testOrder) { o.Address.City, o.Address.Zip = "", "12" }, []string{"address.city", "address.zip"}},
+		{"nil pointer is missing and not walked", func(o *testOrder) { o.Address = nil }, []string{"address"}},
+		{"slice elements under the form name", func(o *testOrder) {
+			o.Items = append(o.Items, testItem{SKU: "bad sku", Qty: 0})
+		}, []string{"items.1.qty", "items.1.sku"}},
+		{"empty slice", func(o *testOrder) { o.Items = nil }, []string{"items"}},
+		{"each rules on scalar slices", func(o *testOrder) { o.Tags = []string{"ok", "toolong", "n0"} }, []string{"tags.1", "tags.2"}},
+		{"each rules on maps", func(o *testOrder) { o.Meta["note"] = "long" }, []string{"meta.note"}},
+		{"embedded struct shares the keys", func(o *testOrder) {
+			old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			o.PublishAt = &old
+		}, []string{"publish_at"}},
+		{"nullable nil pointer", func(o *testOrder) { o.PublishAt = nil }, nil},
+		{"time is a leaf", func(o *testOrder) { o.Created = time.Time{} }, nil},
+		{"upload", func(o *testOrder) { o.Avatar = &multipart.FileHeader{Filename: "a.png"} }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := validOrder()
+			tt.change(&order)
+
+			v, err := Struct(&order)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("Struct: %v %v", err, v.Errors)
+				}
+				return
+			}
+			if !errors.Is(err, ErrValidationFailed) {
+				t.Fatalf("Struct: got %v, want ErrValidationFailed", err)
+			}
+			var fields []string
+			for field := range v.Errors {
+				fields = append(fields, field)
+			}
+			if !sameFields(fields, tt.wantFields) {
+				t.Fatalf("errors on %v, want %v: %v", fields, tt.wantFields, v.Errors)
+			}
+		})
+	}
+}
+
+func sameFields(got, want []string) bool {
+	seen := make(map[string]bool)
+	for _, f := range got {
+		seen[f] = true
+	}
+	if len(seen) != len(want) {
+		return false
+	}
+	for _, f := range want {
+		if !seen[f] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStructRejectsNonStructs(t *testing.T) {
+	var nilOrder *testOrder
+	for _, s := range []interface{}{nil, "order", nilOrder, []testOrder{}} {
+		if _, err := Struct(s); err == nil || errors.Is(err, ErrValidationFailed) {
+			t.Errorf("Struct(%#v) = %v, want a usage error", s, err)
+		}
+	}
+}
+
+func TestStructReportsUnknownRules(t *testing.T) {
+	type request struct {
+		Title string `json:"title" validate:"required|maxlen:10"`
+	}
+	if _, err := Struct(request{Title: "Hello"}); !errors.Is(err, ErrUnknownRule) {
+		t.Fatalf("Struct: got %v, want ErrUnknownRule", err)
+	}
+}
+
+func TestFlattenStruct(t *testing.T) {
+	order := validOrder()
+	data, rules := FlattenStruct(&order)
+
+	wantData := map[string]interface{}{
+		"email":        "ada@example.com",
+		"address.city": "Dhaka",
+		"items.0.sku":  "tea-1",
+		"items.0.qty":  2,
+		"tags.0":       "hot",
+		"meta.ref":     "x",
+	}
+	for key, want := range wantData {
+		if got := data[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("data[%q] = %#v, want %#v", key, got, want)
+		}
+	}
+	if _, ok := data["Secret"]; ok {
+		t.Error(`a json:"-" field was flattened`)
+	}
+
+	wantRules := map[string]string{
+		"items.0.sku": "required|alpha_dash",
+		"tags.0":      "alpha|max:5",
+		"publish_at":  "nullable|after:2025-01-01",
+	}
+	for key, want := range wantRules {
+		if rules[key] != want {
+			t.Errorf("rules[%q] = %q, want %q", key, rules[key], want)
+		}
+	}
+}
@@ -2,15 +2,28 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
-	"regexp"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 )
 
+var (
+	ErrValidationFailed = errors.New("validation failed")
+	ErrUnknownRule      = errors.New("unknown validation rule")
+)
+
 type Validator struct {
 	Data   map[string]interface{}
 	Errors map[string][]string
+	err    error
+
+	messages   []*Messages       // locale first, then DefaultLocale
+	custom     map[string]string // "rule" or "field.rule" -> message
+	attributes map[string]string
 }
 
 func NewValidator(data map[string]interface{}) *Validator {
@@ -20,117 +33,207 @@ func NewValidator(data map[string]interface{}) *Validator {
 	}
 }
 
+// Locale switches the messages to a locale from resources/lang. Messages the
+// locale lacks fall back to DefaultLocale and then to the built-in English.
+func (v *Validator) Locale(locale string) *Validator {
+	v.messages = nil
+	for _, name := range []string{locale, DefaultLocale} {
+		if m, err := LoadMessages(name); err == nil {
+			v.messages = append(v.messages, m)
+		}
+	}
+	return v
+}
+
+// SetMessages overrides messages for this validator. Keys are a rule name
+// ("required") or a field and rule ("email.required"); numeric segments of
+// nested keys may be written as "*" ("items.*.qty.min").
+func (v *Validator) SetMessages(messages map[string]string) *Validator {
+	v.custom = messages
+	return v
+}
+
+// SetAttributes sets the display names used for :attribute, e.g.
+// "email" -> "email address"
+func (v *Validator) SetAttributes(attributes map[string]string) *Validator {
+	v.attributes = attributes
+	return v
+}
+
+// Validate checks the data against pipe-delimited rule strings
 func (v *Validator) Validate(rules map[string]string) bool {
+	mixed := make(map[string][]interface{}, len(rules))
 	for field, ruleStr := range rules {
-		rules := strings.Split(ruleStr, "|")
-		value, exists := v.Data[field]
-
-		for _, rule := range rules {
-			parts := strings.SplitN(rule, ":", 2)
-			ruleName := parts[0]
-			var ruleValue string
-			if len(parts) > 1 {
-				ruleValue = parts[1]
-			}
+		mixed[field] = []interface{}{ruleStr}
+	}
+	return v.ValidateRules(mixed)
+}
 
-			// Skip validation if field doesn't exist and isn't required
-			if !exists && ruleName != "required" {
-				continue
-			}
+// ValidateRules accepts rule strings and Rule values per field, so closure
+// rules can be used inline:
+//
+//	v.ValidateRules(map[string][]interface{}{
+//		"code": {"required|alpha_num", validation.Func(checkCode, "The :attribute is not a valid code")},
+//	})
+func (v *Validator) ValidateRules(rules map[string][]interface{}) bool {
+	// Sorted so errors and bail behaviour do not depend on map order
+	fields := make([]string, 0, len(rules))
+	for field := range rules {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
 
-			switch ruleName {
-			case "required":
-				if !v.validateRequired(field, value) {
-					v.addError(field, "The %s field is required", field)
-				}
-			case "email":
-				if !v.validateEmail(field, value) {
-					v.addError(field, "The %s must be a valid email address", field)
-				}
-			case "min":
-				if !v.validateMin(field, value, ruleValue) {
-					v.addError(field, "The %s must be at least %s characters", field, ruleValue)
-				}
-			case "max":
-				if !v.validateMax(field, value, ruleValue) {
-					v.addError(field, "The %s may not be greater than %s characters", field, ruleValue)
-				}
-			case "numeric":
-				if !v.validateNumeric(field, value) {
-					v.addError(field, "The %s must be a number", field)
-				}
-				// Add more validation rules as needed
-			}
-		}
+	for _, field := range fields {
+		v.validateField(field, parseRules(rules[field]))
 	}
 
 	return len(v.Errors) == 0
 }
 
-func (v *Validator) validateRequired(field string, value interface{}) bool {
-	if value == nil {
-		return false
+func (v *Validator) validateField(field string, entries []ruleEntry) {
+	value, exists := v.Data[field]
+
+	sometimes := hasRule(entries, "sometimes")
+	nullable := hasRule(entries, "nullable")
+	bail := hasRule(entries, "bail")
+
+	// sometimes: only validate the field when it is present at all
+	if sometimes && !exists {
+		return
 	}
 
-	if str, ok := value.(string); ok {
-		return strings.TrimSpace(str) != ""
+	// Numeric strings are compared by value when the field is numeric
+	if hasRule(entries, "numeric") || hasRule(entries, "integer") {
+		value = numericCast(value)
 	}
 
-	return true
+	for _, entry := range entries {
+		if entry.rule == nil {
+			v.unknownRule(field, entry.name)
+			continue
+		}
+		if b, ok := entry.rule.(*builtinRule); ok && b.flag {
+			continue
+		}
+
+		// Skip validation if field is missing or empty and the rule isn't implicit
+		if !isImplicit(entry.rule) {
+			if !exists || isEmptyString(value) {
+				continue
+			}
+			if nullable && value == nil {
+				continue
+			}
+		}
+
+		if !v.passes(field, value, entry) {
+			v.addError(field, "%s", v.message(field, value, entry))
+			if bail {
+				return
+			}
+		}
+	}
 }
 
-func (v *Validator) validateEmail(field string, value interface{}) bool {
-	str, ok := value.(string)
+func (v *Validator) passes(field string, value interface{}, entry ruleEntry) bool {
+	fallible, ok := entry.rule.(FallibleRule)
 	if !ok {
-		return false
+		return entry.rule.Passes(field, value, entry.params, v.Data)
 	}
 
-	emailRegex := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
-	return emailRegex.MatchString(str)
+	passed, err := fallible.Check(field, value, entry.params, v.Data)
+	if err != nil && v.err == nil {
+		v.err = err
+	}
+	return passed
 }
 
-func (v *Validator) validateMin(field string, value interface{}, minStr string) bool {
-	min := parseInt(minStr)
+// Err reports problems with the rules themselves, such as unknown rule
+// names, as opposed to invalid data.
+func (v *Validator) Err() error {
+	return v.err
+}
 
-	switch val := value.(type) {
-	case string:
-		return utf8.RuneCountInString(val) >= min
-	case int:
-		return val >= min
-	case float64:
-		return val >= float64(min)
-	default:
-		return false
+func (v *Validator) unknownRule(field, ruleName string) {
+	if v.err == nil {
+		v.err = fmt.Errorf("%w %q on field %s", ErrUnknownRule, ruleName, field)
 	}
+	v.addError(field, "The %s field has an unknown validation rule %s", field, ruleName)
 }
 
-func (v *Validator) validateMax(field string, value interface{}, maxStr string) bool {
-	max := parseInt(maxStr)
+func (v *Validator) message(field string, value interface{}, entry ruleEntry) string {
+	message, ok := v.translate(field, entry.name, sizeType(value))
+	if !ok {
+		message = entry.rule.Message()
+		if b, ok := entry.rule.(*builtinRule); ok {
+			if typed, ok := b.messages[sizeType(value)]; ok {
+				message = typed
+			}
+		}
+	}
+	return formatMessage(message, v.attribute(field), value, entry)
+}
+
+// translate looks a message up in the validator's own messages, then in the
+// locale files: field specific messages before rule messages.
+func (v *Validator) translate(field, rule, valueType string) (string, bool) {
+	keys := []string{field, wildcardKey(field)}
 
-	switch val := value.(type) {
-	case string:
-		return utf8.RuneCountInString(val) <= max
-	case int:
-		return val <= max
-	case float64:
-		return val <= float64(max)
-	default:
-		return false
+	for _, key := range keys {
+		if message, ok := v.custom[key+"."+rule]; ok {
+			return message, true
+		}
 	}
+	if message, ok := v.custom[rule]; ok {
+		return message, true
+	}
+
+	for _, m := range v.messages {
+		for _, key := range keys {
+			if message, ok := m.Custom[key][rule]; ok {
+				return message, true
+			}
+		}
+		if variants, ok := m.Rules[rule]; ok {
+			if message, ok := variants[valueType]; ok {
+				return message, true
+			}
+			if message, ok := variants[""]; ok {
+				return message, true
+			}
+		}
+	}
+	return "", false
 }
 
-func (v *Validator) validateNumeric(field string, value interface{}) bool {
-	switch value.(type) {
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
-		return true
-	case string:
-		// Check if it's a numeric string
-		str := value.(string)
-		matched, _ := regexp.MatchString(`^\-?\d+(\.\d+)?$`, str)
-		return matched
-	default:
-		return false
+// attribute returns the display name of a field
+func (v *Validator) attribute(field string) string {
+	keys := []string{field, wildcardKey(field)}
+	for _, key := range keys {
+		if name, ok := v.attributes[key]; ok {
+			return name
+		}
+	}
+	for _, m := range v.messages {
+		for _, key := range keys {
+			if name, ok := m.Attributes[key]; ok {
+				return name
+			}
+		}
 	}
+	return field
+}
+
+// wildcardKey replaces the index segments of a nested key with "*", so
+// "items.0.qty" matches "items.*.qty"
+func wildcardKey(field string) string {
+	segments := strings.Split(field, ".")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, ".")
 }
 
 func (v *Validator) addError(field, format string, args ...interface{}) {
@@ -146,8 +249,90 @@ func (v *Validator) GetErrors() map[string][]string {
 	return v.Errors
 }
 
-func parseInt(s string) int {
-	var n int
-	fmt.Sscanf(s, "%d", &n)
-	return n
+func hasRule(entries []ruleEntry, name string) bool {
+	for _, entry := range entries {
+		if entry.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isImplicit(rule Rule) bool {
+	implicit, ok := rule.(ImplicitRule)
+	return ok && implicit.Implicit()
+}
+
+func isEmptyString(value interface{}) bool {
+	str, ok := value.(string)
+	return ok && strings.TrimSpace(str) == ""
+}
+
+// numericCast turns numeric strings into float64 so size rules compare the
+// value instead of the character count
+func numericCast(value interface{}) interface{} {
+	if str, ok := value.(string); ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// sizeOf measures a value the way min, max, size and between compare it:
+// strings by character count, numbers by value and collections by length.
+func sizeOf(value interface{}) (float64, bool) {
+	if str, ok := value.(string); ok {
+		return float64(utf8.RuneCountInString(str)), true
+	}
+	if num, ok := numericValue(value); ok {
+		return num, true
+	}
+	if n, ok := lengthOf(value); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// sizeType picks the message variant of size rules
+func sizeType(value interface{}) string {
+	if _, ok := value.(string); ok {
+		return "string"
+	}
+	if _, ok := numericValue(value); ok {
+		return "numeric"
+	}
+	if _, ok := lengthOf(value); ok {
+		return "array"
+	}
+	return ""
+}
+
+// numericValue converts any Go number to float64
+func numericValue(value interface{}) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// lengthOf returns the element count of slices, arrays and maps
+func lengthOf(value interface{}) (int, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len(), true
+	}
+	return 0, false
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
 }
@@ -2,7 +2,10 @@
 package routes
 
 import (
+	"log"
+
 	"mygola/app/http/controllers"
+	"mygola/database"
 	"mygola/pkg/routing"
 	"mygola/pkg/view"
 	"mygola/pkg/web"
@@ -20,7 +23,11 @@ func RegisterWebRoutes(router *routing.Router, templateEngine *view.TemplateEngi
 
 	// Get template engine from container (in a real app, you'd use dependency injection)
 	//templateEngine := view.NewTemplateEngine("views", "app")
-	blogController := controllers.NewBlogController(templateEngine)
+	blogORM, err := database.ORM("")
+	if err != nil {
+		log.Printf("blog routes: %v", err)
+	}
+	blogController := controllers.NewBlogController(templateEngine, blogORM)
 
 	router.Get("/", homeController.Index)
 	router.Get("/about", homeController.About)